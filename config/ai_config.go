@@ -2,13 +2,14 @@ package config
 
 import (
 	"context"
-
-	"log"
+	"errors"
 	"os"
 
 	"google.golang.org/genai"
 )
 
+var ErrNoEmbeddings = errors.New("no embeddings returned")
+
 func Ai(prompt string) (string, error) {
 	ctx := context.Background()
 
@@ -18,7 +19,7 @@ func Ai(prompt string) (string, error) {
 	}
 	client, err := genai.NewClient(ctx, config)
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
 	result, err := client.Models.GenerateContent(
@@ -28,14 +29,15 @@ func Ai(prompt string) (string, error) {
 		nil,
 	)
 	if err != nil {
-		log.Fatal(err)
 		return "", err
 	}
 	//fmt.Println(result.Text())
 	return result.Text(), nil
 }
 
-func AIEmbeddings(content string) []float32 {
+// AIEmbeddings embeds content. Errors are returned rather than exiting so
+// a failed job is retried by its queue's retry policy.
+func AIEmbeddings(content string) ([]float32, error) {
 	api_key := os.Getenv("GEMINI_API_KEY")
 	ctx := context.Background()
 	config := &genai.ClientConfig{
@@ -43,7 +45,7 @@ func AIEmbeddings(content string) []float32 {
 	}
 	client, err := genai.NewClient(ctx, config)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	contents := []*genai.Content{
@@ -55,14 +57,12 @@ func AIEmbeddings(content string) []float32 {
 		nil,
 	)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	//embeddings, err := json.MarshalIndent(result.Embeddings, "", "  ")
-	if err != nil {
-		log.Fatal(err)
+	if len(result.Embeddings) == 0 {
+		return nil, ErrNoEmbeddings
 	}
-	//fmt.Println(string(embeddings))
 
-	return result.Embeddings[0].Values
+	return result.Embeddings[0].Values, nil
 }
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// AppURL is the frontend base URL used in links sent to users.
+func AppURL() string {
+	if url := os.Getenv("APP_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000"
+}
+
+// FeedCacheTTL is how stale a cached home feed is allowed to get.
+func FeedCacheTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("FEED_CACHE_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 2 * time.Minute
+}
+
+// PostArchiveAge is how old a post gets before it is archived and stops
+// accepting votes and comments.
+func PostArchiveAge() time.Duration {
+	if age, err := time.ParseDuration(os.Getenv("POST_ARCHIVE_AGE")); err == nil && age > 0 {
+		return age
+	}
+	return 180 * 24 * time.Hour
+}
+
+// MigrateOnStart reports whether pending database migrations run when the
+// server starts. Set MIGRATE_ON_START=false to only run them with the
+// migrate subcommand.
+func MigrateOnStart() bool {
+	return os.Getenv("MIGRATE_ON_START") != "false"
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/jobs/workers"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gocraft/work"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type jobQueues struct {
+	Namespace string        `json:"namespace"`
+	Queues    []*work.Queue `json:"queues"`
+	Retrying  int64         `json:"retrying"`
+	Dead      int64         `json:"dead"`
+}
+
+func jobNamespace(c *gin.Context) (string, bool) {
+	namespace := c.Param("namespace")
+	if !workers.Manager.HasNamespace(namespace) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job namespace not found"})
+		return "", false
+	}
+	return namespace, true
+}
+
+func GetJobQueues() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response := []jobQueues{}
+
+		for _, namespace := range workers.Manager.Namespaces() {
+			client := workers.Manager.Client(namespace)
+
+			queues, err := client.Queues()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching job queues", "details": err.Error()})
+				return
+			}
+
+			_, retrying, err := client.RetryJobs(1)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching retry jobs", "details": err.Error()})
+				return
+			}
+
+			_, dead, err := client.DeadJobs(1)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching dead jobs", "details": err.Error()})
+				return
+			}
+
+			response = append(response, jobQueues{
+				Namespace: namespace,
+				Queues:    queues,
+				Retrying:  retrying,
+				Dead:      dead,
+			})
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func GetPendingJobs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		namespace, ok := jobNamespace(c)
+		if !ok {
+			return
+		}
+
+		jobName := c.Param("job_name")
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit < 1 || limit > 100 {
+			limit = 20
+		}
+
+		pending, err := workers.Manager.PendingJobs(namespace, jobName, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching pending jobs", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, pending)
+	}
+}
+
+func GetRetryJobs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		namespace, ok := jobNamespace(c)
+		if !ok {
+			return
+		}
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if page < 1 {
+			page = 1
+		}
+
+		retrying, count, err := workers.Manager.Client(namespace).RetryJobs(uint(page))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching retry jobs", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"jobs": retrying, "count": count})
+	}
+}
+
+func GetDeadJobs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		namespace, ok := jobNamespace(c)
+		if !ok {
+			return
+		}
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if page < 1 {
+			page = 1
+		}
+
+		dead, count, err := workers.Manager.Client(namespace).DeadJobs(uint(page))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching dead jobs", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"jobs": dead, "count": count})
+	}
+}
+
+func RetryDeadJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		namespace, ok := jobNamespace(c)
+		if !ok {
+			return
+		}
+
+		diedAt, err := strconv.ParseInt(c.Param("died_at"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid died_at", "details": err.Error()})
+			return
+		}
+
+		if err := workers.Manager.Client(namespace).RetryDeadJob(diedAt, c.Param("job_id")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrying dead job", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "job queued for retry"})
+	}
+}
+
+func DeleteDeadJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		namespace, ok := jobNamespace(c)
+		if !ok {
+			return
+		}
+
+		diedAt, err := strconv.ParseInt(c.Param("died_at"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid died_at", "details": err.Error()})
+			return
+		}
+
+		if err := workers.Manager.Client(namespace).DeleteDeadJob(diedAt, c.Param("job_id")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting dead job", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "dead job deleted"})
+	}
+}
+
+func DeleteRetryJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		namespace, ok := jobNamespace(c)
+		if !ok {
+			return
+		}
+
+		retryAt, err := strconv.ParseInt(c.Param("retry_at"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid retry_at", "details": err.Error()})
+			return
+		}
+
+		if err := workers.Manager.Client(namespace).DeleteRetryJob(retryAt, c.Param("job_id")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting retry job", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "retry job deleted"})
+	}
+}
+
+func GetScheduledJobs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var scheduledJobs []models.ScheduledJob
+
+		cursor, err := database.ScheduledJobCollection.Find(ctx, bson.M{})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching scheduled jobs", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		if err := cursor.All(ctx, &scheduledJobs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding scheduled jobs", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, scheduledJobs)
+	}
+}
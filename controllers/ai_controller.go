@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/EsanSamuel/Reddit_Clone/config"
 	"github.com/EsanSamuel/Reddit_Clone/database"
 	"github.com/EsanSamuel/Reddit_Clone/helpers"
 	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -67,6 +70,7 @@ func ThreadsSummary() gin.HandlerFunc {
 		summary, err := config.Ai(prompt)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error summarizing posts", "details": err.Error()})
+			return
 		}
 
 		logger.INFO(summary)
@@ -84,7 +88,11 @@ func SeachPostDetailsWithAI() gin.HandlerFunc {
 		postId := c.Param("postId")
 		query := c.Query("query")
 
-		embedding := config.AIEmbeddings(query)
+		embedding, err := config.AIEmbeddings(query)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Error embedding query", "details": err.Error()})
+			return
+		}
 		if embedding != nil {
 			queryEmbeddings := embedding
 			fmt.Println(queryEmbeddings)
@@ -168,3 +176,144 @@ func SeachPostDetailsWithAI() gin.HandlerFunc {
 		}
 	}
 }
+
+func SuggestPostDetails() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var draft models.PostDraft
+
+		if err := c.ShouldBindJSON(&draft); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding draft payload", "details": err.Error()})
+			return
+		}
+
+		if strings.TrimSpace(draft.Title) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
+			return
+		}
+		draftText := strings.TrimSpace(draft.Title + "\n" + draft.Content)
+
+		draftEmbeddings, err := config.AIEmbeddings(draftText)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Error embedding draft", "details": err.Error()})
+			return
+		}
+
+		suggestion := models.PostSuggestion{
+			Tags:       []models.TagSuggestion{},
+			Subreddits: []models.SubredditSuggestion{},
+			Duplicates: []models.DuplicatePost{},
+			Hints:      []string{},
+		}
+
+		centroids, err := services.SubredditCentroids(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding subreddit centroids", "details": err.Error()})
+			return
+		}
+
+		for subredditId, centroid := range centroids {
+			suggestion.Subreddits = append(suggestion.Subreddits, models.SubredditSuggestion{
+				SubredditID: subredditId,
+				Score:       helpers.CosineSimilarity(draftEmbeddings, centroid),
+			})
+		}
+		sort.Slice(suggestion.Subreddits, func(i, j int) bool {
+			return suggestion.Subreddits[i].Score > suggestion.Subreddits[j].Score
+		})
+		if len(suggestion.Subreddits) > 3 {
+			suggestion.Subreddits = suggestion.Subreddits[:3]
+		}
+
+		for i, subredditSuggestion := range suggestion.Subreddits {
+			var subreddit models.SubReddit
+			if err := database.SubredditCollection.FindOne(ctx, bson.M{"subreddit_id": subredditSuggestion.SubredditID}).Decode(&subreddit); err == nil {
+				suggestion.Subreddits[i].Name = subreddit.Name
+			}
+		}
+
+		// Tags and duplicates come from the chosen subreddit, or the best match
+		targetSubreddit := draft.SubredditID
+		if targetSubreddit == "" && len(suggestion.Subreddits) > 0 {
+			targetSubreddit = suggestion.Subreddits[0].SubredditID
+		}
+
+		lowerDraft := strings.ToLower(draftText)
+		tagScores := make(map[string]float32)
+
+		if targetSubreddit != "" {
+			err = services.EachSubredditEmbedding(ctx, targetSubreddit, func(post models.Post) {
+				similarity := helpers.CosineSimilarity(draftEmbeddings, post.Embeddings)
+
+				// Tags are drawn from similar posts, with a boost when the draft mentions the tag
+				for _, tag := range post.Tags {
+					score := similarity
+					if strings.Contains(lowerDraft, strings.ToLower(tag)) {
+						score += 0.2
+					}
+					if score > tagScores[tag] {
+						tagScores[tag] = score
+					}
+				}
+
+				if similarity >= 0.9 {
+					suggestion.Duplicates = append(suggestion.Duplicates, models.DuplicatePost{
+						PostID:      post.PostID,
+						Title:       post.Title,
+						SubredditID: post.SubredditID,
+						Similarity:  similarity,
+					})
+				}
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding posts", "details": err.Error()})
+				return
+			}
+		}
+
+		for tag, score := range tagScores {
+			if score > 0.5 {
+				suggestion.Tags = append(suggestion.Tags, models.TagSuggestion{Tag: tag, Score: score})
+			}
+		}
+		sort.Slice(suggestion.Tags, func(i, j int) bool {
+			return suggestion.Tags[i].Score > suggestion.Tags[j].Score
+		})
+		if len(suggestion.Tags) > 5 {
+			suggestion.Tags = suggestion.Tags[:5]
+		}
+
+		sort.Slice(suggestion.Duplicates, func(i, j int) bool {
+			return suggestion.Duplicates[i].Similarity > suggestion.Duplicates[j].Similarity
+		})
+
+		suggestion.Hints = postQualityHints(draft, len(suggestion.Duplicates) > 0)
+
+		c.JSON(http.StatusOK, suggestion)
+	}
+}
+
+func postQualityHints(draft models.PostDraft, hasDuplicates bool) []string {
+	hints := []string{}
+	title := strings.TrimSpace(draft.Title)
+
+	if len(title) < 15 {
+		hints = append(hints, "Title is short, descriptive titles get more engagement")
+	}
+	if len(title) > 300 {
+		hints = append(hints, "Title is longer than 300 characters")
+	}
+	if title != "" && title == strings.ToUpper(title) && title != strings.ToLower(title) {
+		hints = append(hints, "Avoid writing the title in all caps")
+	}
+	if len(strings.TrimSpace(draft.Content)) < 30 {
+		hints = append(hints, "Add more detail to the body so others can respond")
+	}
+	if hasDuplicates {
+		hints = append(hints, "Similar posts already exist, consider joining those discussions")
+	}
+
+	return hints
+}
@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -10,8 +12,11 @@ import (
 
 	"github.com/EsanSamuel/Reddit_Clone/database"
 	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/realtime"
+	"github.com/EsanSamuel/Reddit_Clone/services"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
@@ -27,6 +32,22 @@ func CreateComment() gin.HandlerFunc {
 			return
 		}
 
+		if err := services.CheckCommentable(ctx, comment.PostID); err != nil {
+			switch {
+			case errors.Is(err, services.ErrPostLocked), errors.Is(err, services.ErrPostArchived):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case errors.Is(err, mongo.ErrNoDocuments):
+				c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error checking post", "details": err.Error()})
+			}
+			return
+		}
+
+		// Comments are always written as the signed-in user
+		comment.AuthorID = c.GetString("userId")
+		comment.Score = 0
+		comment.CommentCount = 0
 		comment.CreatedAt = time.Now()
 		comment.UpdatedAt = time.Now()
 		comment.CommentID = bson.NewObjectID().Hex()
@@ -43,6 +64,15 @@ func CreateComment() gin.HandlerFunc {
 			if comment.ParentID != "" {
 				database.CommentCollection.UpdateOne(ctx, bson.M{"parent_id": comment.ParentID}, bson.M{"$inc": bson.M{"comment_count": 1}})
 			}
+
+			publishComment(ctx, comment)
+
+			if err := notifyReply(ctx, comment); err != nil {
+				fmt.Println("Error notifying reply:", err.Error())
+			}
+			if err := services.NotifyMentions(ctx, comment.AuthorID, comment.Content, comment.PostID, comment.CommentID); err != nil {
+				fmt.Println("Error notifying mentions:", err.Error())
+			}
 		}
 
 		c.JSON(http.StatusCreated, gin.H{"comment": comment, "result": result})
@@ -93,9 +123,9 @@ func GetPostComments() gin.HandlerFunc {
 		if sort := strings.TrimSpace(c.Query("sort")); sort != "" {
 			switch sort {
 			case "asc":
-				findOptions.SetSort(bson.D{{"created_at", 1}})
+				findOptions.SetSort(bson.D{{Key: "created_at", Value: 1}})
 			case "desc":
-				findOptions.SetSort(bson.D{{"created_at", -1}})
+				findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
 			}
 
 		}
@@ -165,9 +195,9 @@ func GetParentComments() gin.HandlerFunc {
 		if sort := strings.TrimSpace(c.Query("sort")); sort != "" {
 			switch sort {
 			case "asc":
-				findOptions.SetSort(bson.D{{"created_at", 1}})
+				findOptions.SetSort(bson.D{{Key: "created_at", Value: 1}})
 			case "desc":
-				findOptions.SetSort(bson.D{{"created_at", -1}})
+				findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
 			}
 
 		}
@@ -213,3 +243,112 @@ func GetCommentById() gin.HandlerFunc {
 		c.JSON(http.StatusOK, comment)
 	}
 }
+
+// notifyReply tells the author of the parent comment, or of the post for
+// top-level comments, that someone replied.
+func notifyReply(ctx context.Context, comment models.Comment) error {
+	notification := models.Notification{
+		ActorID:   comment.AuthorID,
+		PostID:    comment.PostID,
+		CommentID: comment.CommentID,
+	}
+
+	if comment.ParentID != "" {
+		var parent models.Comment
+		if err := database.CommentCollection.FindOne(ctx, bson.M{"comment_id": comment.ParentID}).Decode(&parent); err != nil {
+			return err
+		}
+		notification.UserID = parent.AuthorID
+		notification.Type = models.NotificationCommentReply
+		notification.Message = services.ActorName(ctx, comment.AuthorID) + " replied to your comment"
+	} else {
+		var post models.Post
+		if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": comment.PostID}).Decode(&post); err != nil {
+			return err
+		}
+		notification.UserID = post.AuthorID
+		notification.SubredditID = post.SubredditID
+		notification.Type = models.NotificationPostReply
+		notification.Message = services.ActorName(ctx, comment.AuthorID) + " commented on your post \"" + post.Title + "\""
+	}
+
+	return services.Notify(ctx, notification)
+}
+
+func publishComment(ctx context.Context, comment models.Comment) {
+	channels := []string{realtime.PostChannel(comment.PostID)}
+
+	var post models.Post
+	if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": comment.PostID}).Decode(&post); err == nil && post.SubredditID != "" {
+		channels = append(channels, realtime.SubredditChannel(post.SubredditID))
+	}
+
+	realtime.PublishAll(ctx, realtime.CommentCreated, comment, channels...)
+}
+
+func VoteComment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var vote models.VoteDTO
+
+		if err := c.ShouldBindJSON(&vote); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding vote payload", "details": err.Error()})
+			return
+		}
+
+		comment, result, err := services.VoteComment(ctx, c.GetString("userId"), c.Param("id"), vote.Value)
+		if err != nil {
+			voteError(c, err)
+			return
+		}
+
+		if result.Changed {
+			data := gin.H{"comment_id": comment.CommentID, "post_id": comment.PostID, "score": comment.Score}
+			realtime.PublishAll(ctx, realtime.CommentVoteChanged, data, realtime.PostChannel(comment.PostID))
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func DeleteComment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		commentId := c.Param("id")
+
+		var comment models.Comment
+
+		err := database.CommentCollection.FindOne(ctx, bson.M{"comment_id": commentId}).Decode(&comment)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error finding comment", "details": err.Error()})
+			return
+		}
+
+		if comment.AuthorID != c.GetString("userId") && c.GetString("role") != "ADMIN" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own comments"})
+			return
+		}
+
+		// Replies stay in the thread, so the comment is blanked instead of removed
+		update := bson.M{
+			"$set": bson.M{
+				"content":    "[deleted]",
+				"author_url": "",
+				"updated_at": time.Now(),
+			},
+		}
+
+		if _, err := database.CommentCollection.UpdateOne(ctx, bson.M{"comment_id": commentId}, update); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting comment", "details": err.Error()})
+			return
+		}
+
+		realtime.PublishAll(ctx, realtime.CommentRemoved, gin.H{"comment_id": commentId, "post_id": comment.PostID}, realtime.PostChannel(comment.PostID))
+
+		c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
+	}
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func GetHomeFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+
+		feed, err := services.HomeFeed(ctx, c.GetString("userId"), c.Query("sort"), c.Query("t"), page)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error building home feed", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, feed)
+	}
+}
+
+func GetRecommendedFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(services.FeedPerPage)))
+		if err != nil || limit < 1 || limit > 100 {
+			limit = services.FeedPerPage
+		}
+
+		posts, err := services.RecommendedPosts(ctx, c.GetString("userId"), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error building recommendations", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"posts": posts})
+	}
+}
+
+func UpdateContentSettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var settings models.ContentSettingsDTO
+
+		if err := c.ShouldBindJSON(&settings); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding content settings", "details": err.Error()})
+			return
+		}
+
+		update := bson.M{"$set": bson.M{"show_nsfw": settings.ShowNSFW, "updated_at": time.Now()}}
+
+		if _, err := database.UserCollection.UpdateOne(ctx, bson.M{"user_id": c.GetString("userId")}, update); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating content settings", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
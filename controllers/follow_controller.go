@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/helpers"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func FollowUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+
+		created, err := services.Follow(ctx, userId, c.Param("userId"))
+		switch {
+		case errors.Is(err, services.ErrFollowSelf):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, services.ErrFollowBlocked):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error following user", "details": err.Error()})
+			return
+		}
+
+		if created {
+			services.InvalidateHomeFeed(ctx, userId)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "You are following this user"})
+	}
+}
+
+func UnfollowUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+
+		removed, err := services.Unfollow(ctx, userId, c.Param("userId"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unfollowing user", "details": err.Error()})
+			return
+		}
+
+		if removed {
+			services.InvalidateHomeFeed(ctx, userId)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "You are no longer following this user"})
+	}
+}
+
+// listFollows pages through follows matching field = userId and returns the
+// profiles found in otherField, newest follow first.
+func listFollows(c *gin.Context, field string, otherField string) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	filter, err := helpers.CursorFilter(bson.M{field: c.Param("userId")}, c.Query("cursor"), "created_at", otherField)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := helpers.CursorLimit(c.Query("limit"))
+	findOptions := options.Find().
+		SetSort(helpers.CursorSort("created_at", otherField)).
+		SetLimit(limit)
+
+	cursor, err := database.FollowCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching follows", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var follows []models.Follow
+	if err := cursor.All(ctx, &follows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding follows", "details": err.Error()})
+		return
+	}
+
+	userIds := make([]string, 0, len(follows))
+	for _, follow := range follows {
+		if otherField == "follower_id" {
+			userIds = append(userIds, follow.FollowerID)
+		} else {
+			userIds = append(userIds, follow.FolloweeID)
+		}
+	}
+
+	profiles, err := services.FindProfilesByIDs(ctx, userIds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching profiles", "details": err.Error()})
+		return
+	}
+
+	page := models.CursorPage[models.PublicProfileDTO]{Items: profiles}
+	if int64(len(follows)) == limit {
+		last := follows[len(follows)-1]
+		page.NextCursor = helpers.EncodeCursor(last.CreatedAt, userIds[len(userIds)-1])
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func GetFollowers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listFollows(c, "followee_id", "follower_id")
+	}
+}
+
+func GetFollowing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listFollows(c, "follower_id", "followee_id")
+	}
+}
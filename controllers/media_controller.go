@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/jobs/workers"
+	"github.com/EsanSamuel/Reddit_Clone/media"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/storage"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// mediaError maps upload validation errors to 4xx responses.
+func mediaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, media.ErrUnsupportedType), errors.Is(err, media.ErrCorrupt), errors.Is(err, media.ErrEmpty):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, media.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, media.ErrTypeMismatch), errors.Is(err, media.ErrChecksumMismatch):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, media.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, media.ErrUploadMissing), errors.Is(err, media.ErrNotMultipart), errors.Is(err, media.ErrInvalidPart):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, storage.ErrDirectUploadUnsupported):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error uploading file", "details": err.Error()})
+	}
+}
+
+func UploadMedia() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error reading file", "details": err.Error()})
+			return
+		}
+
+		uploaded, err := media.UploadFile(ctx, c.GetString("userId"), media.PurposePost, file)
+		if err != nil {
+			mediaError(c, err)
+			return
+		}
+
+		if uploaded.Status == models.MEDIA_PROCESSING {
+			if err := workers.MediaProcessingQueue(uploaded.MediaID); err != nil {
+				fmt.Println("Error queuing media processing:", err.Error())
+			}
+		}
+
+		c.JSON(http.StatusCreated, uploaded)
+	}
+}
+
+// CreateUploadSlot starts a direct upload. The client sends the file to
+// the returned URL (or part URLs) and then calls ConfirmUpload.
+func CreateUploadSlot() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var payload models.UploadSlotDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding upload payload", "details": err.Error()})
+			return
+		}
+
+		slot, err := media.CreateUploadSlot(ctx, c.GetString("userId"), payload)
+		if err != nil {
+			mediaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, slot)
+	}
+}
+
+// SignUploadParts re-issues part URLs so an interrupted multipart upload
+// can resume.
+func SignUploadParts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var payload models.SignUploadPartsDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding parts payload", "details": err.Error()})
+			return
+		}
+
+		parts, err := media.SignUploadParts(ctx, c.GetString("userId"), c.Param("id"), payload.PartNumbers)
+		if err != nil {
+			mediaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, parts)
+	}
+}
+
+func ConfirmUpload() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		var payload models.ConfirmUploadDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding confirm payload", "details": err.Error()})
+			return
+		}
+
+		confirmed, err := media.ConfirmUpload(ctx, c.GetString("userId"), c.Param("id"), payload)
+		if err != nil {
+			mediaError(c, err)
+			return
+		}
+
+		if confirmed.Status == models.MEDIA_PROCESSING {
+			if err := workers.MediaProcessingQueue(confirmed.MediaID); err != nil {
+				fmt.Println("Error queuing media processing:", err.Error())
+			}
+		}
+
+		c.JSON(http.StatusOK, confirmed)
+	}
+}
+
+func AbortUpload() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := media.AbortUpload(ctx, c.GetString("userId"), c.Param("id")); err != nil {
+			mediaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Upload cancelled"})
+	}
+}
+
+func GetMedia() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var found models.Media
+
+		if err := database.MediaCollection.FindOne(ctx, bson.M{"media_id": c.Param("id")}).Decode(&found); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+			return
+		}
+
+		media.Sign(&found)
+		c.JSON(http.StatusOK, found)
+	}
+}
+
+func GetPostMedia() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var post models.Post
+
+		if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": c.Param("id")}).Decode(&post); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+
+		found, err := media.FindByIDs(ctx, post.MediaIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching media", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, found)
+	}
+}
@@ -0,0 +1,490 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/helpers"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/realtime"
+	"github.com/EsanSamuel/Reddit_Clone/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func CreateConversation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+
+		var payload models.CreateConversationDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding conversation payload", "details": err.Error()})
+			return
+		}
+
+		participantIds := []string{userId}
+		for _, participantId := range payload.ParticipantIDs {
+			if participantId != "" && !slices.Contains(participantIds, participantId) {
+				participantIds = append(participantIds, participantId)
+			}
+		}
+		sort.Strings(participantIds)
+
+		if len(participantIds) < 2 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a conversation needs at least one other participant"})
+			return
+		}
+		if len(participantIds) > services.MaxGroupParticipants {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "too many participants"})
+			return
+		}
+
+		participantCount, err := database.UserCollection.CountDocuments(ctx, bson.M{"user_id": bson.M{"$in": participantIds}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding participants", "details": err.Error()})
+			return
+		}
+		if int(participantCount) != len(participantIds) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "one or more participants do not exist"})
+			return
+		}
+
+		conversationType := models.ConversationGroup
+		if len(participantIds) == 2 {
+			conversationType = models.ConversationDirect
+		}
+
+		conversation := models.Conversation{
+			Type:           conversationType,
+			Title:          payload.Title,
+			ParticipantIDs: participantIds,
+			CreatorID:      userId,
+		}
+
+		allowed, err := services.CanMessage(ctx, userId, conversation)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking blocks", "details": err.Error()})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot message one or more of these users"})
+			return
+		}
+
+		// Direct conversations are reused rather than duplicated
+		if conversationType == models.ConversationDirect {
+			var existing models.Conversation
+			err := database.ConversationCollection.FindOne(ctx, bson.M{"type": models.ConversationDirect, "participant_ids": participantIds}).Decode(&existing)
+			if err == nil {
+				conversation = existing
+			} else if !errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding conversation", "details": err.Error()})
+				return
+			}
+		}
+
+		if conversation.ConversationID == "" {
+			conversation.ConversationID = bson.NewObjectID().Hex()
+			conversation.ReadReceipts = map[string]time.Time{}
+			conversation.CreatedAt = time.Now()
+			conversation.UpdatedAt = time.Now()
+			conversation.LastMessageAt = time.Now()
+
+			if _, err := database.ConversationCollection.InsertOne(ctx, conversation); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating conversation", "details": err.Error()})
+				return
+			}
+		}
+
+		if strings.TrimSpace(payload.Body) != "" {
+			if _, err := sendMessage(ctx, conversation, userId, payload.Body); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending message", "details": err.Error()})
+				return
+			}
+		}
+
+		c.JSON(http.StatusCreated, conversation)
+	}
+}
+
+func CreateModmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+
+		var payload models.CreateModmailDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding modmail payload", "details": err.Error()})
+			return
+		}
+
+		subredditCount, err := database.SubredditCollection.CountDocuments(ctx, bson.M{"subreddit_id": payload.SubredditID})
+		if err != nil || subredditCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Subreddit not found"})
+			return
+		}
+
+		conversation := models.Conversation{
+			ConversationID: bson.NewObjectID().Hex(),
+			Type:           models.ConversationModmail,
+			Title:          payload.Title,
+			ParticipantIDs: []string{userId},
+			SubredditID:    payload.SubredditID,
+			CreatorID:      userId,
+			ReadReceipts:   map[string]time.Time{},
+			LastMessageAt:  time.Now(),
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+
+		if _, err := database.ConversationCollection.InsertOne(ctx, conversation); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating modmail", "details": err.Error()})
+			return
+		}
+
+		if _, err := sendMessage(ctx, conversation, userId, payload.Body); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending message", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, conversation)
+	}
+}
+
+func GetConversations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+
+		moderated, err := services.ModeratedSubredditIDs(ctx, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding moderated subreddits", "details": err.Error()})
+			return
+		}
+
+		filter := bson.M{
+			"$or": []bson.M{
+				{"participant_ids": userId},
+				{"type": models.ConversationModmail, "subreddit_id": bson.M{"$in": moderated}},
+			},
+		}
+		if c.Query("type") != "" {
+			filter = bson.M{"$and": []bson.M{filter, {"type": strings.ToUpper(c.Query("type"))}}}
+		}
+
+		filter, err = helpers.CursorFilter(filter, c.Query("cursor"), "last_message_at", "conversation_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		limit := helpers.CursorLimit(c.Query("limit"))
+		findOptions := options.Find().
+			SetSort(helpers.CursorSort("last_message_at", "conversation_id")).
+			SetLimit(limit)
+
+		cursor, err := database.ConversationCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching conversations", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		conversations := []models.Conversation{}
+		if err := cursor.All(ctx, &conversations); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding conversations", "details": err.Error()})
+			return
+		}
+
+		page := models.CursorPage[models.Conversation]{Items: conversations}
+		if int64(len(conversations)) == limit {
+			last := conversations[len(conversations)-1]
+			page.NextCursor = helpers.EncodeCursor(last.LastMessageAt, last.ConversationID)
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// findConversation loads a conversation and checks the user can see it,
+// writing the error response when they cannot.
+func findConversation(c *gin.Context, ctx context.Context) (models.Conversation, bool) {
+	var conversation models.Conversation
+
+	err := database.ConversationCollection.FindOne(ctx, bson.M{"conversation_id": c.Param("id")}).Decode(&conversation)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return conversation, false
+	}
+
+	allowed, err := services.CanAccessConversation(ctx, c.GetString("userId"), conversation)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking conversation access", "details": err.Error()})
+		return conversation, false
+	}
+	if !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return conversation, false
+	}
+
+	return conversation, true
+}
+
+func GetConversationMessages() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		conversation, ok := findConversation(c, ctx)
+		if !ok {
+			return
+		}
+
+		filter, err := helpers.CursorFilter(bson.M{"conversation_id": conversation.ConversationID}, c.Query("cursor"), "created_at", "message_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		limit := helpers.CursorLimit(c.Query("limit"))
+		findOptions := options.Find().
+			SetSort(helpers.CursorSort("created_at", "message_id")).
+			SetLimit(limit)
+
+		cursor, err := database.MessageCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching messages", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		messages := []models.Message{}
+		if err := cursor.All(ctx, &messages); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding messages", "details": err.Error()})
+			return
+		}
+
+		page := models.CursorPage[models.Message]{Items: messages}
+		if int64(len(messages)) == limit {
+			last := messages[len(messages)-1]
+			page.NextCursor = helpers.EncodeCursor(last.CreatedAt, last.MessageID)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"conversation": conversation, "messages": page})
+	}
+}
+
+func SendMessage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+
+		var payload models.SendMessageDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil || strings.TrimSpace(payload.Body) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "message body is required"})
+			return
+		}
+
+		conversation, ok := findConversation(c, ctx)
+		if !ok {
+			return
+		}
+
+		allowed, err := services.CanMessage(ctx, userId, conversation)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking blocks", "details": err.Error()})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot message this conversation"})
+			return
+		}
+
+		message, err := sendMessage(ctx, conversation, userId, payload.Body)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending message", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, message)
+	}
+}
+
+func sendMessage(ctx context.Context, conversation models.Conversation, senderId string, body string) (models.Message, error) {
+	message := models.Message{
+		MessageID:      bson.NewObjectID().Hex(),
+		ConversationID: conversation.ConversationID,
+		SenderID:       senderId,
+		Body:           body,
+		CreatedAt:      time.Now(),
+	}
+
+	if _, err := database.MessageCollection.InsertOne(ctx, message); err != nil {
+		return message, err
+	}
+
+	// Sending a message also marks the conversation as read for the sender
+	update := bson.M{
+		"$set": bson.M{
+			"last_message_at":           message.CreatedAt,
+			"updated_at":                message.CreatedAt,
+			"read_receipts." + senderId: message.CreatedAt,
+		},
+	}
+	if _, err := database.ConversationCollection.UpdateOne(ctx, bson.M{"conversation_id": conversation.ConversationID}, update); err != nil {
+		return message, err
+	}
+
+	var channels []string
+	for _, recipientId := range services.ConversationRecipients(ctx, conversation) {
+		if recipientId != senderId {
+			channels = append(channels, realtime.UserChannel(recipientId))
+		}
+	}
+	realtime.PublishAll(ctx, realtime.MessageCreated, message, channels...)
+
+	return message, nil
+}
+
+func MarkConversationRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+
+		conversation, ok := findConversation(c, ctx)
+		if !ok {
+			return
+		}
+
+		readAt := time.Now()
+		update := bson.M{"$set": bson.M{"read_receipts." + userId: readAt}}
+
+		if _, err := database.ConversationCollection.UpdateOne(ctx, bson.M{"conversation_id": conversation.ConversationID}, update); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating read receipt", "details": err.Error()})
+			return
+		}
+
+		var channels []string
+		for _, recipientId := range services.ConversationRecipients(ctx, conversation) {
+			if recipientId != userId {
+				channels = append(channels, realtime.UserChannel(recipientId))
+			}
+		}
+		realtime.PublishAll(ctx, realtime.ConversationRead, gin.H{"conversation_id": conversation.ConversationID, "user_id": userId, "read_at": readAt}, channels...)
+
+		c.JSON(http.StatusOK, gin.H{"message": "Conversation marked as read"})
+	}
+}
+
+func BlockUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var block models.Block
+
+		if err := c.ShouldBindJSON(&block); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding block payload", "details": err.Error()})
+			return
+		}
+
+		block.UserID = c.GetString("userId")
+		block.CreatedAt = time.Now()
+
+		if block.BlockedUserID == "" || block.BlockedUserID == block.UserID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user to block"})
+			return
+		}
+
+		filter := bson.M{"user_id": block.UserID, "blocked_user_id": block.BlockedUserID}
+		update := bson.M{"$setOnInsert": block}
+
+		if _, err := database.BlockCollection.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error blocking user", "details": err.Error()})
+			return
+		}
+
+		services.InvalidateHomeFeed(ctx, block.UserID, block.BlockedUserID)
+
+		c.JSON(http.StatusOK, gin.H{"message": "User blocked"})
+	}
+}
+
+func UnblockUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"user_id": c.GetString("userId"), "blocked_user_id": c.Param("user_id")}
+
+		if _, err := database.BlockCollection.DeleteOne(ctx, filter); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unblocking user", "details": err.Error()})
+			return
+		}
+
+		services.InvalidateHomeFeed(ctx, c.GetString("userId"), c.Param("user_id"))
+
+		c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+	}
+}
+
+func GetBlockedUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter, err := helpers.CursorFilter(bson.M{"user_id": c.GetString("userId")}, c.Query("cursor"), "created_at", "blocked_user_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		limit := helpers.CursorLimit(c.Query("limit"))
+		findOptions := options.Find().
+			SetSort(helpers.CursorSort("created_at", "blocked_user_id")).
+			SetLimit(limit)
+
+		cursor, err := database.BlockCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching blocked users", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		blocks := []models.Block{}
+		if err := cursor.All(ctx, &blocks); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding blocked users", "details": err.Error()})
+			return
+		}
+
+		page := models.CursorPage[models.Block]{Items: blocks}
+		if int64(len(blocks)) == limit {
+			last := blocks[len(blocks)-1]
+			page.NextCursor = helpers.EncodeCursor(last.CreatedAt, last.BlockedUserID)
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
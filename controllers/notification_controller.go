@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func GetNotifications() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+
+		filter := services.InboxFilter(userId)
+		if c.Query("unread") == "true" {
+			filter["read"] = false
+		}
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		perPage := 20
+
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetSkip((int64(page) - 1) * int64(perPage)).
+			SetLimit(int64(perPage))
+
+		cursor, err := database.NotificationCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching notifications", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		notifications := []models.Notification{}
+		if err := cursor.All(ctx, &notifications); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding notifications", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, notifications)
+	}
+}
+
+func GetUnreadNotificationCount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := services.InboxFilter(c.GetString("userId"))
+		filter["read"] = false
+
+		count, err := database.NotificationCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting notifications", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"unread": count})
+	}
+}
+
+func MarkNotificationRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := services.InboxFilter(c.GetString("userId"))
+		filter["notification_id"] = c.Param("id")
+
+		result, err := database.NotificationCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"read": true}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating notification", "details": err.Error()})
+			return
+		}
+
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+	}
+}
+
+func MarkAllNotificationsRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := services.InboxFilter(c.GetString("userId"))
+		filter["read"] = false
+
+		result, err := database.NotificationCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"read": true}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating notifications", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read", "updated": result.ModifiedCount})
+	}
+}
+
+func GetNotificationPreferences() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var user models.User
+
+		if err := database.UserCollection.FindOne(ctx, bson.M{"user_id": c.GetString("userId")}).Decode(&user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching user", "details": err.Error()})
+			return
+		}
+
+		preferences := make(map[string]models.NotificationPreference)
+		for _, notificationType := range models.NotificationTypes {
+			preferences[notificationType] = services.Preference(user, notificationType)
+		}
+
+		c.JSON(http.StatusOK, models.NotificationPreferencesDTO{Preferences: preferences})
+	}
+}
+
+func UpdateNotificationPreferences() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var payload models.NotificationPreferencesDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding preferences payload", "details": err.Error()})
+			return
+		}
+
+		set := bson.M{"updated_at": time.Now()}
+		for _, notificationType := range models.NotificationTypes {
+			if preference, ok := payload.Preferences[notificationType]; ok {
+				set["notification_preferences."+notificationType] = preference
+			}
+		}
+
+		_, err := database.UserCollection.UpdateOne(ctx, bson.M{"user_id": c.GetString("userId")}, bson.M{"$set": set})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating preferences", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Notification preferences updated"})
+	}
+}
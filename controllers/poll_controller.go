@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func VotePoll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var vote models.PollVoteDTO
+
+		if err := c.ShouldBindJSON(&vote); err != nil || vote.OptionID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "option_id is required"})
+			return
+		}
+
+		summary, err := services.VotePoll(ctx, c.GetString("userId"), c.Param("id"), vote.OptionID)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		case errors.Is(err, services.ErrNotPoll), errors.Is(err, services.ErrInvalidPollOption):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPostArchived):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPollClosed), errors.Is(err, services.ErrAlreadyVoted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error voting in poll", "details": err.Error()})
+		default:
+			c.JSON(http.StatusOK, summary)
+		}
+	}
+}
+
+// GetPoll returns a poll's summary, with results once the signed-in user
+// has voted or the poll has closed.
+func GetPoll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var post models.Post
+
+		if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": c.Param("id")}).Decode(&post); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+
+		if post.Poll == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrNotPoll.Error()})
+			return
+		}
+
+		posts := []models.Post{post}
+		if err := services.AttachPollSummaries(ctx, c.GetString("userId"), posts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting poll results", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, posts[0].PollSummary)
+	}
+}
@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -10,10 +12,14 @@ import (
 
 	"github.com/EsanSamuel/Reddit_Clone/database"
 	"github.com/EsanSamuel/Reddit_Clone/jobs/workers"
+	"github.com/EsanSamuel/Reddit_Clone/links"
+	"github.com/EsanSamuel/Reddit_Clone/media"
 	"github.com/EsanSamuel/Reddit_Clone/models"
-	"github.com/EsanSamuel/Reddit_Clone/utils"
+	"github.com/EsanSamuel/Reddit_Clone/realtime"
+	"github.com/EsanSamuel/Reddit_Clone/services"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
@@ -45,20 +51,120 @@ func CreatePost() gin.HandlerFunc {
 			return
 		}
 
+		// The author is always the signed-in user, whatever the payload says
+		post.AuthorID = c.GetString("userId")
+
+		// Moderators set these later through ModeratePost
+		post.Locked = false
+		post.Stickied = false
+		post.Archived = false
+
+		// Only Crosspost links posts together
+		post.CrosspostParentID = ""
+		post.CrosspostParentSubredditID = ""
+		post.CrosspostParentAuthorID = ""
+		post.CrosspostCount = 0
+		post.CrosspostedTo = nil
+
+		// Uploaded files become media documents that the post references
 		if isMultipart {
 			if form, _ := c.MultipartForm(); form != nil {
-				if files, ok := form.File["files"]; ok && len(files) > 0 {
-					post.FileUrls = utils.UploadFiles(c, files)
+				for _, file := range form.File["files"] {
+					uploaded, err := media.UploadFile(ctx, post.AuthorID, media.PurposePost, file)
+					if err != nil {
+						mediaError(c, err)
+						return
+					}
+					post.MediaIDs = append(post.MediaIDs, uploaded.MediaID)
 				}
 			}
 		}
 
+		if err := services.PrepareLinkPost(&post); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := services.PreparePoll(&post); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := services.PrepareDraft(&post); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := services.CheckPostingRules(ctx, post.AuthorID, post.SubredditID); err != nil {
+			switch {
+			case errors.Is(err, services.ErrInsufficientKarma), errors.Is(err, services.ErrBanned):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case errors.Is(err, mongo.ErrNoDocuments):
+				c.JSON(http.StatusNotFound, gin.H{"error": "subreddit not found"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error checking subreddit rules", "details": err.Error()})
+			}
+			return
+		}
+
 		post.PostID = bson.NewObjectID().Hex()
 		post.CreatedAt = time.Now()
 		post.UpdatedAt = time.Now()
 		post.Score = 0
 
-		result, err := database.PostCollection.InsertOne(ctx, post)
+		// Drafts and scheduled posts are only counted and queued when published
+		if services.IsDraft(post) {
+			if err := services.SaveDraft(ctx, post); err != nil {
+				if errors.Is(err, media.ErrNotAttachable) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "error saving draft",
+					"details": err.Error(),
+				})
+				return
+			}
+
+			queueMediaProcessing(post.MediaIDs)
+
+			c.JSON(http.StatusCreated, gin.H{
+				"message":      "draft saved successfully",
+				"post_id":      post.PostID,
+				"status":       post.Status,
+				"scheduled_at": post.ScheduledAt,
+			})
+			return
+		}
+
+		// The post, its subreddit counter and the embedding job are committed together.
+		// Posts without a subreddit go to the author's profile.
+		err := database.WithTransaction(ctx, func(ctx context.Context) error {
+			if _, err := database.PostCollection.InsertOne(ctx, post); err != nil {
+				return err
+			}
+
+			if err := media.Attach(ctx, post.PostID, post.AuthorID, post.MediaIDs); err != nil {
+				return err
+			}
+
+			if post.SubredditID != "" {
+				_, err := database.SubredditCollection.UpdateOne(
+					ctx,
+					bson.M{"subreddit_id": post.SubredditID},
+					bson.M{"$inc": bson.M{"posts_count": 1}},
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			return workers.PostPublishedOutbox(ctx, post)
+		})
+		if errors.Is(err, media.ErrNotAttachable) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":   "error creating post",
@@ -67,13 +173,12 @@ func CreatePost() gin.HandlerFunc {
 			return
 		}
 
-		if result.Acknowledged {
-			_, _ = database.SubredditCollection.UpdateOne(
-				ctx,
-				bson.M{"subreddit_id": post.SubredditID},
-				bson.M{"$inc": bson.M{"posts_count": 1}},
-			)
-			workers.AIEmbeddingQueue(post.PostID)
+		workers.Manager.NotifyOutbox()
+
+		queueMediaProcessing(post.MediaIDs)
+
+		if err := services.NotifyMentions(ctx, post.AuthorID, post.Title+"\n"+post.Content, post.PostID, ""); err != nil {
+			fmt.Println("Error notifying mentions:", err.Error())
 		}
 
 		c.JSON(http.StatusCreated, gin.H{
@@ -83,6 +188,80 @@ func CreatePost() gin.HandlerFunc {
 	}
 }
 
+func queueMediaProcessing(mediaIds []string) {
+	for _, mediaId := range mediaIds {
+		if err := workers.MediaProcessingQueue(mediaId); err != nil {
+			fmt.Println("Error queuing media processing:", err.Error())
+		}
+	}
+}
+
+func CrosspostPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var payload models.CrosspostDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil || payload.SubredditID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "subreddit_id is required"})
+			return
+		}
+
+		crosspost, err := services.Crosspost(ctx, c.GetString("userId"), c.Param("id"), payload, workers.AIEmbeddingOutbox)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusNotFound, gin.H{"error": "post or subreddit not found"})
+			return
+		case errors.Is(err, services.ErrBanned), errors.Is(err, services.ErrInsufficientKarma), errors.Is(err, services.ErrCrosspostsDisabled):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, services.ErrCrosspostSameSubreddit):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, services.ErrAlreadyCrossposted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error crossposting", "details": err.Error()})
+			return
+		}
+
+		workers.Manager.NotifyOutbox()
+
+		c.JSON(http.StatusCreated, crosspost)
+	}
+}
+
+// GetCrossposts lists where a post has been shared.
+func GetCrossposts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		skip, limit := profilePage(c)
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetSkip(skip).
+			SetLimit(limit)
+
+		cursor, err := database.PostCollection.Find(ctx, bson.M{"crosspost_parent_id": c.Param("id")}, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching crossposts", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		posts := []models.Post{}
+		if err := cursor.All(ctx, &posts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error decoding crossposts", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, posts)
+	}
+}
+
 func GetPosts() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
@@ -114,13 +293,23 @@ func GetPosts() gin.HandlerFunc {
 			}
 		}
 
+		// Signed-in users don't see posts they have hidden
+		hidden, err := services.HiddenPostIDs(ctx, c.GetString("userId"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error getting hidden posts", "details": err.Error()})
+			return
+		}
+		if len(hidden) > 0 {
+			filter["post_id"] = bson.M{"$nin": hidden}
+		}
+
 		// Sort Subreddit
 		if sort := strings.TrimSpace(c.Query("sort")); sort != "" {
 			switch sort {
 			case "asc":
-				findOptions.SetSort(bson.D{{"created_at", 1}})
+				findOptions.SetSort(bson.D{{Key: "created_at", Value: 1}})
 			case "desc":
-				findOptions.SetSort(bson.D{{"created_at", -1}})
+				findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
 			}
 
 		}
@@ -142,6 +331,12 @@ func GetPosts() gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error decoding subreddit post", "details": err.Error()})
 			return
 		}
+
+		if err := services.AttachPollSummaries(ctx, c.GetString("userId"), posts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error getting poll results", "details": err.Error()})
+			return
+		}
+
 		c.JSON(http.StatusCreated, posts)
 
 	}
@@ -184,16 +379,18 @@ func GetSubRedditPosts() gin.HandlerFunc {
 			}
 		}
 
-		// Sort Subreddit
+		// Stickied posts stay at the top whatever the sort
+		sortOrder := bson.D{{Key: "stickied", Value: -1}}
 		if sort := strings.TrimSpace(c.Query("sort")); sort != "" {
 			switch sort {
 			case "asc":
-				findOptions.SetSort(bson.D{{"created_at", 1}})
+				sortOrder = append(sortOrder, bson.E{Key: "created_at", Value: 1})
 			case "desc":
-				findOptions.SetSort(bson.D{{"created_at", -1}})
+				sortOrder = append(sortOrder, bson.E{Key: "created_at", Value: -1})
 			}
 
 		}
+		findOptions.SetSort(sortOrder)
 
 		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 		perPage := 9
@@ -215,11 +412,49 @@ func GetSubRedditPosts() gin.HandlerFunc {
 			return
 		}
 		defer cursor.Close(ctx)
+
+		if err := services.AttachPollSummaries(ctx, c.GetString("userId"), posts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error getting poll results", "details": err.Error()})
+			return
+		}
+
 		c.JSON(http.StatusCreated, posts)
 
 	}
 }
 
+// GetDomainPosts lists link posts to a domain, newest first or by score
+// with sort=top.
+func GetDomainPosts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		skip, limit := profilePage(c)
+		findOptions := options.Find().
+			SetSort(services.ProfileSort(c.Query("sort"))).
+			SetSkip(skip).
+			SetLimit(limit)
+
+		filter := bson.M{"type": models.LINK_POST, "domain": links.Domain(c.Param("domain"))}
+
+		cursor, err := database.PostCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching domain posts", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		posts := []models.Post{}
+		if err := cursor.All(ctx, &posts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error decoding domain posts", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, posts)
+	}
+}
+
 func GetTagPosts() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
@@ -281,106 +516,331 @@ func GetPostById() gin.HandlerFunc {
 	}
 }
 
-func UpVotePost() gin.HandlerFunc {
+func VotePost() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		var vote models.PostUpvote
+		var vote models.VoteDTO
 
 		if err := c.ShouldBindJSON(&vote); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error binding upvote payload", "details": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding vote payload", "details": err.Error()})
 			return
 		}
 
-		filter := bson.M{
-			"user_id": vote.UserID,
-			"post_id": vote.PostID,
+		post, result, err := services.VotePost(ctx, c.GetString("userId"), c.Param("id"), vote.Value)
+		if err != nil {
+			voteError(c, err)
+			return
 		}
 
-		upvote_counts, err := database.PostUpVoteCollection.CountDocuments(ctx, filter)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting upvote counts", "details": err.Error()})
+		afterPostVote(ctx, post, result)
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// UpVotePost and DownVotePost keep the original endpoints working on top of
+// the vote service for the signed-in user; a user_id in the payload is
+// ignored. Voting the other way switches the vote.
+func UpVotePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var vote models.PostUpvote
+
+		if err := c.ShouldBindJSON(&vote); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error binding upvote payload", "details": err.Error()})
 			return
 		}
-		downvote_counts, err := database.PostDownVoteCollection.CountDocuments(ctx, filter)
+
+		post, result, err := services.VotePost(ctx, c.GetString("userId"), vote.PostID, models.UPVOTE)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting downvote counts", "details": err.Error()})
+			voteError(c, err)
 			return
 		}
-		if upvote_counts > 0 {
+
+		if !result.Changed {
 			c.JSON(http.StatusConflict, gin.H{"error": "User has already upvote"})
 			return
 		}
 
-		if downvote_counts > 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "User has already downvote"})
+		afterPostVote(ctx, post, result)
+
+		c.JSON(http.StatusCreated, result)
+
+	}
+}
+
+func DownVotePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var vote models.PostDownvote
+
+		if err := c.ShouldBindJSON(&vote); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error binding upvote payload", "details": err.Error()})
 			return
 		}
 
-		result, err := database.PostUpVoteCollection.InsertOne(ctx, vote)
+		post, result, err := services.VotePost(ctx, c.GetString("userId"), vote.PostID, models.DOWNVOTE)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error upvoting post", "details": err.Error()})
+			voteError(c, err)
 			return
 		}
 
-		if result.Acknowledged {
-			database.PostCollection.UpdateOne(ctx, bson.M{"post_id": vote.PostID}, bson.M{"$inc": bson.M{"up_vote": 1}})
+		if !result.Changed {
+			c.JSON(http.StatusConflict, gin.H{"error": "User has already downvote"})
+			return
 		}
 
+		afterPostVote(ctx, post, result)
+
 		c.JSON(http.StatusCreated, result)
 
 	}
 }
 
-func DownVotePost() gin.HandlerFunc {
+func voteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidVote):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPostArchived):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Error finding vote target", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error voting", "details": err.Error()})
+	}
+}
+
+func afterPostVote(ctx context.Context, post models.Post, result models.VoteResult) {
+	if !result.Changed {
+		return
+	}
+
+	publishVote(ctx, post)
+
+	if err := services.NotifyScoreMilestone(ctx, post); err != nil {
+		fmt.Println("Error notifying score milestone:", err.Error())
+	}
+}
+
+func publishVote(ctx context.Context, post models.Post) {
+	data := gin.H{
+		"post_id":   post.PostID,
+		"up_vote":   post.UpVote,
+		"down_vote": post.DownVote,
+		"score":     post.Score,
+	}
+
+	channels := []string{realtime.PostChannel(post.PostID)}
+	if post.SubredditID != "" {
+		channels = append(channels, realtime.SubredditChannel(post.SubredditID))
+	}
+
+	realtime.PublishAll(ctx, realtime.PostVoteChanged, data, channels...)
+}
+
+// ModeratePost locks, stickies or flags a post as NSFW or spoiler.
+func ModeratePost() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		var vote models.PostDownvote
+		var payload models.PostModerationDTO
 
-		if err := c.ShouldBindJSON(&vote); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error binding upvote payload", "details": err.Error()})
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding moderation payload", "details": err.Error()})
 			return
 		}
 
-		filter := bson.M{
-			"user_id": vote.UserID,
-			"post_id": vote.PostID,
+		post, err := services.ModeratePost(ctx, c.GetString("userId"), c.GetString("role"), c.Param("id"), payload)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		case errors.Is(err, services.ErrNotModerator):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, services.ErrStickyLimit), errors.Is(err, services.ErrStickyProfile):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error moderating post", "details": err.Error()})
+			return
+		}
+
+		data := gin.H{
+			"post_id":  post.PostID,
+			"locked":   post.Locked,
+			"stickied": post.Stickied,
+			"nsfw":     post.NSFW,
+			"spoiler":  post.Spoiler,
+			"archived": post.Archived,
+		}
+		channels := []string{realtime.PostChannel(post.PostID)}
+		if post.SubredditID != "" {
+			channels = append(channels, realtime.SubredditChannel(post.SubredditID))
 		}
+		realtime.PublishAll(ctx, realtime.PostUpdated, data, channels...)
 
-		upvote_counts, err := database.PostUpVoteCollection.CountDocuments(ctx, filter)
+		c.JSON(http.StatusOK, post)
+	}
+}
+
+// GetDrafts lists the user's drafts and scheduled posts, most recently
+// edited first. ?status=scheduled or ?status=draft narrows the list.
+func GetDrafts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"author_url": c.GetString("userId")}
+		if status := strings.ToUpper(c.Query("status")); status == models.POST_DRAFT || status == models.POST_SCHEDULED {
+			filter["status"] = status
+		}
+
+		skip, limit := profilePage(c)
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+			SetSkip(skip).
+			SetLimit(limit)
+
+		cursor, err := database.DraftCollection.Find(ctx, filter, findOptions)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting upvote counts", "details": err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching drafts", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		drafts := []models.Post{}
+		if err := cursor.All(ctx, &drafts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding drafts", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, drafts)
+	}
+}
+
+func UpdateDraft() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var payload models.DraftDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil || strings.TrimSpace(payload.Title) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
+			return
+		}
+
+		draft, err := services.UpdateDraft(ctx, c.GetString("userId"), c.Param("id"), payload)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Draft not found"})
+			return
+		case errors.Is(err, services.ErrInvalidSchedule):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating draft", "details": err.Error()})
 			return
 		}
-		downvote_counts, err := database.PostDownVoteCollection.CountDocuments(ctx, filter)
+
+		c.JSON(http.StatusOK, draft)
+	}
+}
+
+// PublishDraft publishes one of the user's drafts or scheduled posts now.
+func PublishDraft() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		postId := c.Param("id")
+
+		count, err := database.DraftCollection.CountDocuments(ctx, bson.M{"post_id": postId, "author_url": c.GetString("userId")})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting downvote counts", "details": err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding draft", "details": err.Error()})
 			return
 		}
-		if upvote_counts > 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "User has already upvote"})
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Draft not found"})
 			return
 		}
 
-		if downvote_counts > 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "User has already downvote"})
+		post, err := services.PublishDraft(ctx, postId, workers.PostPublishedOutbox)
+		switch {
+		case errors.Is(err, services.ErrBanned), errors.Is(err, services.ErrInsufficientKarma):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Draft or subreddit not found"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error publishing draft", "details": err.Error()})
 			return
 		}
 
-		result, err := database.PostDownVoteCollection.InsertOne(ctx, vote)
+		workers.Manager.NotifyOutbox()
+
+		c.JSON(http.StatusOK, post)
+	}
+}
+
+func DeleteDraft() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		err := services.DeleteDraft(ctx, c.GetString("userId"), c.Param("id"))
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Draft not found"})
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error downvoting post", "details": err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting draft", "details": err.Error()})
 			return
 		}
 
-		if result.Acknowledged {
-			database.PostCollection.UpdateOne(ctx, bson.M{"post_id": vote.PostID}, bson.M{"$inc": bson.M{"down_vote": -1}})
+		c.JSON(http.StatusOK, gin.H{"message": "Draft deleted"})
+	}
+}
+
+func DeletePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		postId := c.Param("id")
+
+		var post models.Post
+
+		if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": postId}).Decode(&post); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error finding post", "details": err.Error()})
+			return
 		}
 
-		c.JSON(http.StatusCreated, result)
+		if post.AuthorID != c.GetString("userId") && c.GetString("role") != "ADMIN" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own posts"})
+			return
+		}
+
+		if err := services.DeletePost(ctx, post); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting post", "details": err.Error()})
+			return
+		}
+
+		channels := []string{realtime.PostChannel(postId)}
+		if post.SubredditID != "" {
+			channels = append(channels, realtime.SubredditChannel(post.SubredditID))
+		}
+		realtime.PublishAll(ctx, realtime.PostRemoved, gin.H{"post_id": postId, "subreddit_id": post.SubredditID}, channels...)
 
+		c.JSON(http.StatusOK, gin.H{"message": "Post deleted"})
 	}
 }
@@ -0,0 +1,318 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/helpers"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const profilePerPage = 25
+
+func profilePage(c *gin.Context) (int64, int64) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	return int64(page-1) * profilePerPage, profilePerPage
+}
+
+func GetUserPosts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		skip, limit := profilePage(c)
+		findOptions := options.Find().
+			SetSort(services.ProfileSort(c.Query("sort"))).
+			SetSkip(skip).
+			SetLimit(limit)
+
+		filter := bson.M{"author_url": c.Param("userId")}
+		if c.Query("profile") == "true" {
+			filter["subreddit_id"] = ""
+		}
+
+		cursor, err := database.PostCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching user posts", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		posts := []models.Post{}
+		if err := cursor.All(ctx, &posts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error decoding user posts", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, posts)
+	}
+}
+
+func GetUserComments() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		skip, limit := profilePage(c)
+		findOptions := options.Find().
+			SetSort(services.ProfileSort(c.Query("sort"))).
+			SetSkip(skip).
+			SetLimit(limit)
+
+		cursor, err := database.CommentCollection.Find(ctx, bson.M{"author_url": c.Param("userId")}, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching user comments", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		comments := []models.Comment{}
+		if err := cursor.All(ctx, &comments); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error decoding user comments", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, comments)
+	}
+}
+
+func GetUserOverview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		skip, limit := profilePage(c)
+
+		items, err := services.UserOverview(ctx, c.Param("userId"), services.ProfileSort(c.Query("sort")), skip, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching user overview", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+// ownProfile stops users from reading another user's private lists.
+func ownProfile(c *gin.Context) bool {
+	if c.Param("userId") != c.GetString("userId") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This list is only visible to its owner"})
+		return false
+	}
+	return true
+}
+
+// GetVotedPosts lists the posts the user voted on with value, most recent
+// vote first.
+func GetVotedPosts(value int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if !ownProfile(c) {
+			return
+		}
+
+		filter, err := helpers.CursorFilter(bson.M{"user_id": c.Param("userId"), "value": value}, c.Query("cursor"), "updated_at", "post_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		limit := helpers.CursorLimit(c.Query("limit"))
+		findOptions := options.Find().
+			SetSort(helpers.CursorSort("updated_at", "post_id")).
+			SetLimit(limit)
+
+		cursor, err := database.PostVoteCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching votes", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		var votes []models.PostVote
+		if err := cursor.All(ctx, &votes); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error decoding votes", "details": err.Error()})
+			return
+		}
+
+		postIds := make([]string, 0, len(votes))
+		for _, vote := range votes {
+			postIds = append(postIds, vote.PostID)
+		}
+
+		posts, err := services.FindPostsByIDs(ctx, postIds)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching posts", "details": err.Error()})
+			return
+		}
+
+		page := models.CursorPage[models.Post]{Items: posts}
+		if int64(len(votes)) == limit {
+			last := votes[len(votes)-1]
+			page.NextCursor = helpers.EncodeCursor(last.UpdatedAt, last.PostID)
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+func GetSavedItems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if !ownProfile(c) {
+			return
+		}
+
+		filter := bson.M{"user_id": c.Param("userId")}
+		if collection := c.Query("collection"); collection != "" {
+			filter["collection"] = collection
+		}
+
+		filter, err := helpers.CursorFilter(filter, c.Query("cursor"), "created_at", "saved_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		limit := helpers.CursorLimit(c.Query("limit"))
+		findOptions := options.Find().
+			SetSort(helpers.CursorSort("created_at", "saved_id")).
+			SetLimit(limit)
+
+		cursor, err := database.SavedCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching saved items", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		var saved []models.SavedItem
+		if err := cursor.All(ctx, &saved); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error decoding saved items", "details": err.Error()})
+			return
+		}
+
+		items, err := hydrateSavedItems(ctx, saved)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching saved content", "details": err.Error()})
+			return
+		}
+
+		page := models.CursorPage[models.SavedItemDTO]{Items: items}
+		if int64(len(saved)) == limit {
+			last := saved[len(saved)-1]
+			page.NextCursor = helpers.EncodeCursor(last.CreatedAt, last.SavedID)
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// hydrateSavedItems attaches the saved post or comment to each item.
+func hydrateSavedItems(ctx context.Context, saved []models.SavedItem) ([]models.SavedItemDTO, error) {
+	var postIds, commentIds []string
+	for _, item := range saved {
+		if item.CommentID != "" {
+			commentIds = append(commentIds, item.CommentID)
+		} else {
+			postIds = append(postIds, item.PostID)
+		}
+	}
+
+	posts, err := services.FindPostsByIDs(ctx, postIds)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := services.FindCommentsByIDs(ctx, commentIds)
+	if err != nil {
+		return nil, err
+	}
+
+	postsById := make(map[string]models.Post, len(posts))
+	for _, post := range posts {
+		postsById[post.PostID] = post
+	}
+
+	items := make([]models.SavedItemDTO, 0, len(saved))
+	for _, item := range saved {
+		dto := models.SavedItemDTO{SavedItem: item}
+		if item.CommentID != "" {
+			if comment, ok := comments[item.CommentID]; ok {
+				dto.Comment = &comment
+			}
+		} else if post, ok := postsById[item.PostID]; ok {
+			dto.Post = &post
+		}
+		items = append(items, dto)
+	}
+
+	return items, nil
+}
+
+func GetHiddenPosts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if !ownProfile(c) {
+			return
+		}
+
+		filter, err := helpers.CursorFilter(bson.M{"user_id": c.Param("userId")}, c.Query("cursor"), "created_at", "post_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		limit := helpers.CursorLimit(c.Query("limit"))
+		findOptions := options.Find().
+			SetSort(helpers.CursorSort("created_at", "post_id")).
+			SetLimit(limit)
+
+		cursor, err := database.HiddenPostCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching hidden posts", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		var hidden []models.HiddenPost
+		if err := cursor.All(ctx, &hidden); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error decoding hidden posts", "details": err.Error()})
+			return
+		}
+
+		postIds := make([]string, 0, len(hidden))
+		for _, item := range hidden {
+			postIds = append(postIds, item.PostID)
+		}
+
+		posts, err := services.FindPostsByIDs(ctx, postIds)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching posts", "details": err.Error()})
+			return
+		}
+
+		page := models.CursorPage[models.Post]{Items: posts}
+		if int64(len(hidden)) == limit {
+			last := hidden[len(hidden)-1]
+			page.NextCursor = helpers.EncodeCursor(last.CreatedAt, last.PostID)
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/config"
+	"github.com/EsanSamuel/Reddit_Clone/realtime"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     checkOrigin,
+}
+
+// checkOrigin lets browsers connect only from the app itself or the API's
+// own host. Clients that send no Origin are not browsers and are allowed.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	if app, err := url.Parse(config.AppURL()); err == nil &&
+		strings.EqualFold(u.Scheme, app.Scheme) && strings.EqualFold(u.Host, app.Host) {
+		return true
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// realtimeChannels builds the channel list from the post_id and
+// subreddit_id query params. The user's own notification channel is added
+// when notifications=true.
+func realtimeChannels(c *gin.Context) []string {
+	var channels []string
+
+	for _, postId := range c.QueryArray("post_id") {
+		channels = append(channels, realtime.PostChannel(postId))
+	}
+	for _, subredditId := range c.QueryArray("subreddit_id") {
+		channels = append(channels, realtime.SubredditChannel(subredditId))
+	}
+	if c.Query("notifications") == "true" {
+		channels = append(channels, realtime.UserChannel(c.GetString("userId")))
+	}
+
+	return channels
+}
+
+func StreamEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channels := realtimeChannels(c)
+		if len(channels) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "subscribe to at least one post_id, subreddit_id or notifications"})
+			return
+		}
+
+		subscription := realtime.Subscribe(channels...)
+		defer subscription.Close()
+
+		keepAlive := time.NewTicker(30 * time.Second)
+		defer keepAlive.Stop()
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case event := <-subscription.C:
+				c.SSEvent(event.Type, event)
+				return true
+			case <-keepAlive.C:
+				c.SSEvent("ping", time.Now().Unix())
+				return true
+			}
+		})
+	}
+}
+
+func WebSocketEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channels := realtimeChannels(c)
+		if len(channels) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "subscribe to at least one post_id, subreddit_id or notifications"})
+			return
+		}
+
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		subscription := realtime.Subscribe(channels...)
+		defer subscription.Close()
+
+		// The read loop only exists to notice when the client goes away
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		keepAlive := time.NewTicker(30 * time.Second)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case event := <-subscription.C:
+				conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if err := conn.WriteJSON(event); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func SaveItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+
+		var payload models.SaveItemDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding save payload", "details": err.Error()})
+			return
+		}
+
+		collection := strings.TrimSpace(payload.Collection)
+		if collection == "" {
+			collection = services.DefaultSavedCollection
+		}
+
+		// Saved comments keep their post id so they can link back to the thread
+		if payload.CommentID != "" {
+			var comment models.Comment
+			if err := database.CommentCollection.FindOne(ctx, bson.M{"comment_id": payload.CommentID}).Decode(&comment); err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+				return
+			}
+			payload.PostID = comment.PostID
+		} else {
+			count, err := database.PostCollection.CountDocuments(ctx, bson.M{"post_id": payload.PostID})
+			if err != nil || count == 0 {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+				return
+			}
+		}
+
+		filter := bson.M{
+			"user_id":    userId,
+			"post_id":    payload.PostID,
+			"comment_id": payload.CommentID,
+			"collection": collection,
+		}
+		update := bson.M{
+			"$setOnInsert": bson.M{
+				"saved_id":   bson.NewObjectID().Hex(),
+				"created_at": time.Now(),
+			},
+		}
+
+		var saved models.SavedItem
+		err := database.SavedCollection.FindOneAndUpdate(
+			ctx,
+			filter,
+			update,
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&saved)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving item", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, saved)
+	}
+}
+
+func UnsaveItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"saved_id": c.Param("id"), "user_id": c.GetString("userId")}
+
+		result, err := database.SavedCollection.DeleteOne(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error removing saved item", "details": err.Error()})
+			return
+		}
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Saved item not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Item removed from saved"})
+	}
+}
+
+func GetSavedCollections() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"user_id": c.GetString("userId")}}},
+			{{Key: "$group", Value: bson.M{"_id": "$collection", "count": bson.M{"$sum": 1}}}},
+			{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		}
+
+		cursor, err := database.SavedCollection.Aggregate(ctx, pipeline)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching collections", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		collections := []models.SavedCollectionDTO{}
+		if err := cursor.All(ctx, &collections); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding collections", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, collections)
+	}
+}
+
+func RenameSavedCollection() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var payload models.RenameCollectionDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil || strings.TrimSpace(payload.Name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "collection name is required"})
+			return
+		}
+
+		filter := bson.M{"user_id": c.GetString("userId"), "collection": c.Param("name")}
+		update := bson.M{"$set": bson.M{"collection": strings.TrimSpace(payload.Name)}}
+
+		result, err := database.SavedCollection.UpdateMany(ctx, filter, update)
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Some items are already saved in that collection"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error renaming collection", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Collection renamed", "updated": result.ModifiedCount})
+	}
+}
+
+func DeleteSavedCollection() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"user_id": c.GetString("userId"), "collection": c.Param("name")}
+
+		result, err := database.SavedCollection.DeleteMany(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting collection", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Collection deleted", "deleted": result.DeletedCount})
+	}
+}
+
+func HidePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		postId := c.Param("id")
+
+		count, err := database.PostCollection.CountDocuments(ctx, bson.M{"post_id": postId})
+		if err != nil || count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+
+		filter := bson.M{"user_id": c.GetString("userId"), "post_id": postId}
+		update := bson.M{"$setOnInsert": bson.M{"created_at": time.Now()}}
+
+		if _, err := database.HiddenPostCollection.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error hiding post", "details": err.Error()})
+			return
+		}
+
+		services.InvalidateHomeFeed(ctx, c.GetString("userId"))
+
+		c.JSON(http.StatusOK, gin.H{"message": "Post hidden"})
+	}
+}
+
+func UnhidePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"user_id": c.GetString("userId"), "post_id": c.Param("id")}
+
+		if _, err := database.HiddenPostCollection.DeleteOne(ctx, filter); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unhiding post", "details": err.Error()})
+			return
+		}
+
+		services.InvalidateHomeFeed(ctx, c.GetString("userId"))
+
+		c.JSON(http.StatusOK, gin.H{"message": "Post unhidden"})
+	}
+}
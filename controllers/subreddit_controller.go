@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -10,9 +11,12 @@ import (
 	"time"
 
 	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/helpers"
 	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
@@ -22,7 +26,6 @@ func CreateSubreddit() gin.HandlerFunc {
 		defer cancel()
 
 		var subreddit models.SubReddit
-		var member models.SubRedditMembers
 
 		if err := c.ShouldBindJSON(&subreddit); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "error binding subreddit payload", "details": err.Error()})
@@ -32,6 +35,7 @@ func CreateSubreddit() gin.HandlerFunc {
 		subreddit.CreatedAt = time.Now()
 		subreddit.UpdatedAt = time.Now()
 		subreddit.SubRedditId = bson.NewObjectID().Hex()
+		subreddit.MembersCount = 0
 
 		result, err := database.SubredditCollection.InsertOne(ctx, subreddit)
 
@@ -40,61 +44,47 @@ func CreateSubreddit() gin.HandlerFunc {
 			return
 		}
 
-		if result.Acknowledged {
-			member.MemberId = bson.NewObjectID().Hex()
-			member.JoinedAt = time.Now()
-			member.UserID = subreddit.CreatorId
-			member.Role = "MODERATOR"
-			member.SubRedditId = subreddit.SubRedditId
-			_, err := database.MemberCollection.InsertOne(ctx, member)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "error adding creator to the subreddit member", "details": err.Error()})
-				return
-			}
-			database.SubredditCollection.UpdateOne(ctx, bson.M{"subreddit_id": member.SubRedditId}, bson.M{"$inc": bson.M{"members_count": 1}})
+		if _, _, err := services.AddModerator(ctx, subreddit.CreatorId, subreddit.SubRedditId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error adding creator to the subreddit member", "details": err.Error()})
+			return
 		}
+		subreddit.MembersCount = 1
 
 		c.JSON(http.StatusCreated, gin.H{"subreddit": subreddit, "result": result})
 
 	}
 }
 
+// membershipError writes the response for a failed membership change.
+func membershipError(c *gin.Context, err error, message string) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subreddit not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+}
+
 func JoinSubreddit() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		var member models.SubRedditMembers
+		var payload models.SubRedditMembers
 
-		if err := c.ShouldBindJSON(&member); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "error binding member payload", "details": err.Error()})
+		if err := c.ShouldBindJSON(&payload); err != nil || payload.SubRedditId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "subreddit_id is required"})
 			return
 		}
 
-		member.MemberId = bson.NewObjectID().Hex()
-		member.JoinedAt = time.Now()
-
-		member_count, err := database.MemberCollection.CountDocuments(ctx, bson.M{"user_id": member.UserID})
-
+		// Users can only join as themselves
+		member, joined, err := services.JoinSubreddit(ctx, c.GetString("userId"), payload.SubRedditId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting member", "details": err.Error()})
+			membershipError(c, err, "error joining subreddit")
 			return
 		}
 
-		if member_count > 0 {
-			c.JSON(http.StatusConflict, gin.H{"message": "user has already joined this subreddit"})
-			return
-		}
-
-		result, err := database.MemberCollection.InsertOne(ctx, member)
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error joining subreddit", "details": err.Error()})
-			return
-		}
-
-		if result.Acknowledged {
-			database.SubredditCollection.UpdateOne(ctx, bson.M{"subreddit_id": member.SubRedditId}, bson.M{"$inc": bson.M{"members_count": 1}})
+		if joined {
+			services.InvalidateHomeFeed(ctx, member.UserID)
 		}
 
 		c.JSON(http.StatusOK, member)
@@ -106,50 +96,50 @@ func AddModerators() gin.HandlerFunc {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		var member models.SubRedditMembers
-
-		if err := c.ShouldBindJSON(&member); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "error binding member payload", "details": err.Error()})
-			return
-		}
-
-		member.MemberId = bson.NewObjectID().Hex()
-		member.JoinedAt = time.Now()
-		member.Role = "MODERATOR"
-
-		member_count, err := database.MemberCollection.CountDocuments(ctx, bson.M{"user_id": member.UserID})
+		var payload models.SubRedditMembers
 
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting member", "details": err.Error()})
+		if err := c.ShouldBindJSON(&payload); err != nil || payload.UserID == "" || payload.SubRedditId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user_id and subreddit_id are required"})
 			return
 		}
 
-		if member_count > 0 {
-			_, err := database.MemberCollection.UpdateOne(ctx, bson.M{"user_id": member.UserID}, bson.M{"$set": bson.M{"role": "MODERATOR"}})
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "error updating user role to moderator", "details": err.Error()})
-				return
-			}
-
-			c.JSON(http.StatusOK, gin.H{"message": "user role has been changed to moderator"})
+		if !canModerate(c, ctx, payload.SubRedditId) {
 			return
 		}
 
-		result, err := database.MemberCollection.InsertOne(ctx, member)
-
+		member, promoted, err := services.AddModerator(ctx, payload.UserID, payload.SubRedditId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error adding moderator", "details": err.Error()})
+			membershipError(c, err, "error adding moderator")
 			return
 		}
 
-		if result.Acknowledged {
-			database.SubredditCollection.UpdateOne(ctx, bson.M{"subreddit_id": member.SubRedditId}, bson.M{"$inc": bson.M{"members_count": 1}})
+		if promoted {
+			services.InvalidateHomeFeed(ctx, member.UserID)
+			notifyModerator(ctx, member)
 		}
 
 		c.JSON(http.StatusOK, member)
 	}
 }
 
+func notifyModerator(ctx context.Context, member models.SubRedditMembers) {
+	var subreddit models.SubReddit
+	if err := database.SubredditCollection.FindOne(ctx, bson.M{"subreddit_id": member.SubRedditId}).Decode(&subreddit); err != nil {
+		fmt.Println("Error finding subreddit for moderator notification:", err.Error())
+		return
+	}
+
+	err := services.Notify(ctx, models.Notification{
+		UserID:      member.UserID,
+		Type:        models.NotificationModerator,
+		SubredditID: member.SubRedditId,
+		Message:     "You are now a moderator of r/" + subreddit.Name,
+	})
+	if err != nil {
+		fmt.Println("Error notifying moderator:", err.Error())
+	}
+}
+
 func GetSubReddit() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
@@ -185,9 +175,9 @@ func GetSubReddit() gin.HandlerFunc {
 		if sort := strings.TrimSpace(c.Query("sort")); sort != "" {
 			switch sort {
 			case "asc":
-				findOptions.SetSort(bson.D{{"created_at", 1}})
+				findOptions.SetSort(bson.D{{Key: "created_at", Value: 1}})
 			case "desc":
-				findOptions.SetSort(bson.D{{"created_at", -1}})
+				findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
 			}
 
 		}
@@ -233,9 +223,9 @@ func GetSubRedditUserJoined() gin.HandlerFunc {
 		if sort := strings.TrimSpace(c.Query("sort")); sort != "" {
 			switch sort {
 			case "asc":
-				findOptions.SetSort(bson.D{{"joined_at", 1}})
+				findOptions.SetSort(bson.D{{Key: "joined_at", Value: 1}})
 			case "desc":
-				findOptions.SetSort(bson.D{{"joined_at", -1}})
+				findOptions.SetSort(bson.D{{Key: "joined_at", Value: -1}})
 			}
 
 		}
@@ -328,20 +318,216 @@ func GetSubRedditById() gin.HandlerFunc {
 	}
 }
 
-func LeaveSubreddit() gin.HandlerFunc {
+func UpdateSubredditRules() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		user_id := c.Param("user_id")
+		subredditId := c.Param("id")
+
+		var rules models.SubredditRules
+
+		if err := c.ShouldBindJSON(&rules); err != nil || rules.MinKarmaToPost < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subreddit rules"})
+			return
+		}
 
-		_, err := database.MemberCollection.DeleteOne(ctx, bson.M{"user_id": user_id})
+		moderator, err := services.IsModerator(ctx, c.GetString("userId"), subredditId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking moderator", "details": err.Error()})
+			return
+		}
+		if !moderator && c.GetString("role") != "ADMIN" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only moderators can change subreddit rules"})
+			return
+		}
 
+		var subreddit models.SubReddit
+		err = database.SubredditCollection.FindOneAndUpdate(
+			ctx,
+			bson.M{"subreddit_id": subredditId},
+			bson.M{"$set": bson.M{"rules": rules, "updated_at": time.Now()}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&subreddit)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error leaving subreddit", "details": err.Error()})
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error updating subreddit rules", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, subreddit)
+	}
+}
+
+// canModerate reports whether the signed-in user may moderate the subreddit,
+// writing the error response when not.
+func canModerate(c *gin.Context, ctx context.Context, subredditId string) bool {
+	moderator, err := services.IsModerator(ctx, c.GetString("userId"), subredditId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking moderator", "details": err.Error()})
+		return false
+	}
+	if !moderator && c.GetString("role") != "ADMIN" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only moderators can do this"})
+		return false
+	}
+	return true
+}
+
+func BanUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		subredditId := c.Param("id")
+
+		var payload models.BanDTO
+
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error binding ban payload", "details": err.Error()})
+			return
+		}
+
+		if !canModerate(c, ctx, subredditId) {
 			return
 		}
 
-		c.JSON(http.StatusOK, "you have successfully left this subreddit")
+		ban, err := services.BanUser(ctx, subredditId, c.Param("userId"), c.GetString("userId"), payload)
+		switch {
+		case errors.Is(err, services.ErrInvalidBan), errors.Is(err, services.ErrBanModerator):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error banning user", "details": err.Error()})
+		default:
+			c.JSON(http.StatusOK, ban)
+		}
+	}
+}
+
+func UnbanUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		subredditId := c.Param("id")
+
+		if !canModerate(c, ctx, subredditId) {
+			return
+		}
+
+		removed, err := services.UnbanUser(ctx, subredditId, c.Param("userId"), c.GetString("userId"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unbanning user", "details": err.Error()})
+			return
+		}
+		if !removed {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User is not banned"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "User unbanned"})
+	}
+}
+
+func GetSubredditBans() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		subredditId := c.Param("id")
+
+		if !canModerate(c, ctx, subredditId) {
+			return
+		}
+
+		findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+		cursor, err := database.SubredditBanCollection.Find(ctx, bson.M{"subreddit_id": subredditId}, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching bans", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		bans := []models.SubredditBan{}
+		if err := cursor.All(ctx, &bans); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding bans", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, bans)
+	}
+}
+
+// GetModLog lists a subreddit's moderation actions, newest first.
+func GetModLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		subredditId := c.Param("id")
+
+		if !canModerate(c, ctx, subredditId) {
+			return
+		}
+
+		filter := bson.M{"subreddit_id": subredditId}
+		if action := c.Query("action"); action != "" {
+			filter["action"] = action
+		}
+
+		filter, err := helpers.CursorFilter(filter, c.Query("cursor"), "created_at", "log_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		limit := helpers.CursorLimit(c.Query("limit"))
+		findOptions := options.Find().
+			SetSort(helpers.CursorSort("created_at", "log_id")).
+			SetLimit(limit)
+
+		cursor, err := database.ModLogCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching modlog", "details": err.Error()})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		entries := []models.ModLogEntry{}
+		if err := cursor.All(ctx, &entries); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding modlog", "details": err.Error()})
+			return
+		}
+
+		page := models.CursorPage[models.ModLogEntry]{Items: entries}
+		if int64(len(entries)) == limit {
+			last := entries[len(entries)-1]
+			page.NextCursor = helpers.EncodeCursor(last.CreatedAt, last.LogID)
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// LeaveSubreddit removes the signed-in user from a subreddit. Leaving a
+// subreddit they are not in succeeds without changing anything.
+func LeaveSubreddit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+
+		left, err := services.LeaveSubreddit(ctx, userId, c.Param("id"))
+		if err != nil {
+			membershipError(c, err, "Error leaving subreddit")
+			return
+		}
+
+		if left {
+			services.InvalidateHomeFeed(ctx, userId)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "you have successfully left this subreddit", "left": left})
 	}
 }
@@ -2,8 +2,8 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -12,10 +12,14 @@ import (
 
 	"github.com/EsanSamuel/Reddit_Clone/database"
 	"github.com/EsanSamuel/Reddit_Clone/jobs/workers"
+	"github.com/EsanSamuel/Reddit_Clone/media"
 	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
+	"github.com/EsanSamuel/Reddit_Clone/storage"
 	"github.com/EsanSamuel/Reddit_Clone/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -37,12 +41,6 @@ func CreateUser() gin.HandlerFunc {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		user.UserId = bson.NewObjectID().Hex()
-		user.Password = hashedPassword
-		user.CreatedAt = time.Now()
-		user.UpdatedAt = time.Now()
-		user.EmailVerified = false
-
 		userCount, err := database.UserCollection.CountDocuments(ctx, bson.M{"email": user.Email})
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "error counting user"})
@@ -54,6 +52,30 @@ func CreateUser() gin.HandlerFunc {
 			return
 		}
 
+		// Usernames are optional, but mentions need them to be valid and unique
+		user.Username = strings.TrimSpace(user.Username)
+		if user.Username != "" {
+			switch err := services.CheckUsername(ctx, user.Username); {
+			case errors.Is(err, services.ErrInvalidUsername):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			case errors.Is(err, services.ErrUsernameTaken):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			case err != nil:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error checking username", "details": err.Error()})
+				return
+			}
+		}
+
+		// Sign-ups are always regular users, admins are promoted in the database
+		user.Role = "USER"
+		user.UserId = bson.NewObjectID().Hex()
+		user.Password = hashedPassword
+		user.CreatedAt = time.Now()
+		user.UpdatedAt = time.Now()
+		user.EmailVerified = false
+
 		verificationToken, err := utils.GenerateVerificationOrResetToken()
 
 		if err != nil {
@@ -63,17 +85,33 @@ func CreateUser() gin.HandlerFunc {
 
 		user.VerficationToken = verificationToken
 
-		_, err = utils.SendVerificationEmail(user.Email, user.VerficationToken)
-		if err != nil {
-			fmt.Println("Error sending verification mail")
-		}
+		var result *mongo.InsertOneResult
+
+		// The verification email is sent by the email worker once the user is committed
+		err = database.WithTransaction(ctx, func(ctx context.Context) error {
+			result, err = database.UserCollection.InsertOne(ctx, user)
+			if err != nil {
+				return err
+			}
 
-		result, err := database.UserCollection.InsertOne(ctx, user)
+			return workers.VerificationEmailOutbox(ctx, user.Email, user.UserId)
+		})
+		// The unique indexes catch two sign-ups racing past the checks above
+		if mongo.IsDuplicateKeyError(err) {
+			if strings.Contains(err.Error(), "username") {
+				c.JSON(http.StatusConflict, gin.H{"error": services.ErrUsernameTaken.Error()})
+				return
+			}
+			c.JSON(http.StatusConflict, gin.H{"message": "User already exists"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error creating user", "details": err.Error()})
 			return
 		}
 
+		workers.Manager.NotifyOutbox()
+
 		c.JSON(http.StatusCreated, gin.H{"message": "User created", "user": result})
 
 	}
@@ -102,15 +140,19 @@ func VerifyEmail() gin.HandlerFunc {
 			}, "$unset": bson.M{"verification_token": ""},
 		}
 
-		result, err := database.UserCollection.UpdateOne(ctx, bson.M{"user_id": user.UserId}, updateData)
+		err = database.WithTransaction(ctx, func(ctx context.Context) error {
+			if _, err := database.UserCollection.UpdateOne(ctx, bson.M{"user_id": user.UserId}, updateData); err != nil {
+				return err
+			}
+
+			return workers.WelcomeEmailOutbox(ctx, user.Email, user.UserId)
+		})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error verfying user", "details": err.Error()})
 			return
 		}
 
-		if result.Acknowledged {
-			workers.SendEmailQueue(user.Email, user.UserId)
-		}
+		workers.Manager.NotifyOutbox()
 
 		c.JSON(http.StatusOK, gin.H{"message": "User verified"})
 
@@ -360,9 +402,9 @@ func GetAllUsers() gin.HandlerFunc {
 		if sort := strings.TrimSpace(c.Query("sort")); sort != "" {
 			switch sort {
 			case "asc":
-				findOptions.SetSort(bson.D{{"created_at", 1}})
+				findOptions.SetSort(bson.D{{Key: "created_at", Value: 1}})
 			case "desc":
-				findOptions.SetSort(bson.D{{"created_at", -1}})
+				findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
 			}
 
 		}
@@ -386,7 +428,12 @@ func GetAllUsers() gin.HandlerFunc {
 		}
 		defer cursor.Close(ctx)
 
-		c.JSON(http.StatusOK, users)
+		profiles := make([]models.PublicProfileDTO, 0, len(users))
+		for _, user := range users {
+			profiles = append(profiles, services.PublicProfile(user))
+		}
+
+		c.JSON(http.StatusOK, profiles)
 	}
 }
 
@@ -413,7 +460,22 @@ func GetUser() gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, user)
+		c.JSON(http.StatusOK, services.PublicProfile(user))
+	}
+}
+
+func GetUserKarma() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		karma, err := services.GetKarma(ctx, c.Param("userId"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "error fetching karma", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, karma)
 	}
 }
 
@@ -442,7 +504,7 @@ func UploadAvatar() gin.HandlerFunc {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		user_id := c.Param("userId")
+		user_id := c.GetString("userId")
 
 		file, err := c.FormFile("avatar")
 
@@ -451,25 +513,35 @@ func UploadAvatar() gin.HandlerFunc {
 			return
 		}
 
-		src, err := file.Open()
-		fileByte, err := io.ReadAll(src)
-
-		if !utils.IsFileImage(fileByte) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "file is not a image", "details": err.Error()})
+		avatar, err := media.UploadFile(ctx, user_id, media.PurposeAvatar, file)
+		if err != nil {
+			mediaError(c, err)
 			return
 		}
 
-		AvatarUrl, err := utils.UploadSingleFileToS3(src, file.Filename)
+		// The original is shown until the resized avatar is ready
+		avatarKey := media.AvatarKey(avatar)
 
 		updateAvatar := bson.M{
 			"$set": bson.M{
-				"avatar": AvatarUrl,
+				"avatar_key":      avatarKey,
+				"avatar_media_id": avatar.MediaID,
 			},
 		}
 
 		_, err = database.UserCollection.UpdateOne(ctx, bson.M{"user_id": user_id}, updateAvatar)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating avatar", "details": err.Error()})
+			return
+		}
+
+		if avatar.Status == models.MEDIA_PROCESSING {
+			if err := workers.MediaProcessingQueue(avatar.MediaID); err != nil {
+				fmt.Println("Error queuing avatar processing:", err.Error())
+			}
+		}
 
-		c.JSON(http.StatusOK, AvatarUrl)
+		c.JSON(http.StatusOK, storage.URL(avatarKey))
 
 	}
 }
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -62,3 +63,37 @@ var PostCollection *mongo.Collection = Collection("posts")
 var CommentCollection *mongo.Collection = Collection("comments")
 var PostUpVoteCollection *mongo.Collection = Collection("post_upvote")
 var PostDownVoteCollection *mongo.Collection = Collection("post_downvote")
+var OutboxCollection *mongo.Collection = Collection("outbox")
+var ScheduledJobCollection *mongo.Collection = Collection("scheduled_jobs")
+var NotificationCollection *mongo.Collection = Collection("notifications")
+var ConversationCollection *mongo.Collection = Collection("conversations")
+var MessageCollection *mongo.Collection = Collection("messages")
+var BlockCollection *mongo.Collection = Collection("blocks")
+var PostVoteCollection *mongo.Collection = Collection("post_votes")
+var CommentVoteCollection *mongo.Collection = Collection("comment_votes")
+var SubredditKarmaCollection *mongo.Collection = Collection("subreddit_karma")
+var SavedCollection *mongo.Collection = Collection("saved_items")
+var HiddenPostCollection *mongo.Collection = Collection("hidden_posts")
+var FollowCollection *mongo.Collection = Collection("follows")
+var MediaCollection *mongo.Collection = Collection("media")
+var PollVoteCollection *mongo.Collection = Collection("poll_votes")
+var SubredditBanCollection *mongo.Collection = Collection("subreddit_bans")
+var ModLogCollection *mongo.Collection = Collection("modlog")
+var DraftCollection *mongo.Collection = Collection("post_drafts")
+var MigrationCollection *mongo.Collection = Collection("migrations")
+
+// WithTransaction runs fn inside a MongoDB transaction. Every write made with
+// the context passed to fn is committed or rolled back together.
+func WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+
+	return err
+}
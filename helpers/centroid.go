@@ -0,0 +1,31 @@
+package helpers
+
+func Centroid(vectors [][]float32) []float32 {
+	if len(vectors) == 0 {
+		return nil
+	}
+
+	dimension := len(vectors[0])
+	centroid := make([]float32, dimension)
+	count := 0
+
+	for _, vector := range vectors {
+		if len(vector) != dimension {
+			continue
+		}
+		for i := range vector {
+			centroid[i] += vector[i]
+		}
+		count++
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	for i := range centroid {
+		centroid[i] /= float32(count)
+	}
+
+	return centroid
+}
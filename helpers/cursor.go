@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	DefaultCursorLimit = 20
+	MaxCursorLimit     = 100
+)
+
+// Cursor points at the last item of a page sorted by a time field and then
+// by an id field, both descending.
+type Cursor struct {
+	Time time.Time
+	ID   string
+}
+
+func EncodeCursor(t time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.UnixNano(), 10) + ":" + id))
+}
+
+func DecodeCursor(cursor string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	return Cursor{Time: time.Unix(0, unixNano), ID: id}, nil
+}
+
+// CursorFilter adds the conditions for items after the cursor to filter.
+// An empty cursor returns the filter unchanged.
+func CursorFilter(filter bson.M, cursor string, timeField string, idField string) (bson.M, error) {
+	if cursor == "" {
+		return filter, nil
+	}
+
+	decoded, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	after := bson.M{
+		"$or": []bson.M{
+			{timeField: bson.M{"$lt": decoded.Time}},
+			{timeField: decoded.Time, idField: bson.M{"$lt": decoded.ID}},
+		},
+	}
+
+	return bson.M{"$and": []bson.M{filter, after}}, nil
+}
+
+// CursorSort is the sort order matching CursorFilter.
+func CursorSort(timeField string, idField string) bson.D {
+	return bson.D{{Key: timeField, Value: -1}, {Key: idField, Value: -1}}
+}
+
+func CursorLimit(limit string) int64 {
+	n, err := strconv.ParseInt(limit, 10, 64)
+	if err != nil || n < 1 {
+		return DefaultCursorLimit
+	}
+	if n > MaxCursorLimit {
+		return MaxCursorLimit
+	}
+	return n
+}
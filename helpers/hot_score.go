@@ -0,0 +1,25 @@
+package helpers
+
+import (
+	"math"
+	"time"
+)
+
+// hotEpoch is the reference time of Reddit's hot ranking.
+const hotEpoch = 1134028003
+
+// HotScore ranks posts by score with newer posts winning: every 12.5 hours
+// is worth the same as a tenfold increase in score.
+func HotScore(score int, createdAt time.Time) float64 {
+	order := math.Log10(math.Max(math.Abs(float64(score)), 1))
+
+	sign := 0.0
+	if score > 0 {
+		sign = 1
+	} else if score < 0 {
+		sign = -1
+	}
+
+	seconds := float64(createdAt.Unix() - hotEpoch)
+	return sign*order + seconds/45000
+}
@@ -11,7 +11,12 @@ import (
 func ProcessChunks(allChunks []Chunk, queryEmbeddings []float32, query string) ([]float32, string) {
 	for i := range allChunks {
 		if allChunks[i].Embedding == nil {
-			allChunks[i].Embedding = config.AIEmbeddings(allChunks[i].Text)
+			embedding, err := config.AIEmbeddings(allChunks[i].Text)
+			if err != nil {
+				fmt.Println(err)
+				return nil, ""
+			}
+			allChunks[i].Embedding = embedding
 		}
 	}
 
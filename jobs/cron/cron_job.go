@@ -3,53 +3,88 @@ package cron
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/jobs/scheduler"
 	"github.com/EsanSamuel/Reddit_Clone/jobs/workers"
+	"github.com/EsanSamuel/Reddit_Clone/media"
 	"github.com/EsanSamuel/Reddit_Clone/models"
-	"github.com/robfig/cron/v3"
+	"github.com/EsanSamuel/Reddit_Clone/services"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
-func CronJob() {
-	c := cron.New(cron.WithSeconds())
-	defer c.Stop()
+// RegisterJobs adds every scheduled job to the scheduler.
+func RegisterJobs(s *scheduler.Scheduler) error {
+	if err := s.Register("ai_summary_sweep", "@daily", 10*time.Minute, AISummarySweep); err != nil {
+		return err
+	}
+	if err := s.Register("notification_digest", "0 0 8 * * *", 10*time.Minute, NotificationDigest); err != nil {
+		return err
+	}
+	if err := s.Register("karma_reconciliation", "0 30 3 * * *", 30*time.Minute, services.ReconcileKarma); err != nil {
+		return err
+	}
+	if err := s.Register("media_upload_cleanup", "@hourly", 30*time.Minute, media.CleanupUnattached); err != nil {
+		return err
+	}
+	if err := s.Register("post_archival", "0 0 4 * * *", time.Hour, services.ArchiveOldPosts); err != nil {
+		return err
+	}
+	return s.Register("scheduled_post_publisher", "0 * * * * *", time.Minute, PublishScheduledPosts)
+}
 
-	_, err := c.AddFunc("@daily", func() {
-		log.Println("Cron job ran at ", time.Now().Format("15:04:05"))
+// PublishScheduledPosts publishes the scheduled posts that are due and
+// releases their outbox jobs.
+func PublishScheduledPosts(ctx context.Context) error {
+	defer workers.Manager.NotifyOutbox()
+	return services.PublishDuePosts(ctx, workers.PostPublishedOutbox)
+}
 
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		defer cancel()
+// AISummarySweep queues a summary for every post modified in the last day.
+func AISummarySweep(ctx context.Context) error {
+	findOptions := options.Find().SetProjection(bson.M{"post_id": 1})
 
-		var posts []models.Post
+	cursor, err := database.PostCollection.Find(ctx, bson.M{"updated_at": bson.M{"$gte": time.Now().Add(-24 * time.Hour)}}, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
 
-		cursor, err := database.PostCollection.Find(ctx, bson.M{})
+	var posts []models.Post
+	if err := cursor.All(ctx, &posts); err != nil {
+		return err
+	}
 
-		if err != nil {
-			fmt.Println(err)
-			return
+	for _, post := range posts {
+		if err := workers.AISummaryQueue(post.PostID); err != nil {
+			return fmt.Errorf("queuing summary for post %s: %w", post.PostID, err)
 		}
+	}
 
-		if err := cursor.All(ctx, &posts); err != nil {
-			fmt.Println(err)
-			return
-		}
+	return nil
+}
 
-		for _, post := range posts {
-			if time.Since(post.UpdatedAt) >= 24*time.Hour {
-				fmt.Println("Post", post.PostID, "hasn't been modified in the last one day. skipping...")
-				continue
-			}
-			workers.AISummaryQueue(post.PostID)
-		}
+// NotificationDigest queues a digest email for every user with unread
+// notifications from the last day.
+func NotificationDigest(ctx context.Context) error {
+	filter := bson.M{
+		"read":       false,
+		"emailed":    false,
+		"created_at": bson.M{"$gte": time.Now().Add(-24 * time.Hour)},
+	}
 
-	})
-	if err != nil {
-		fmt.Println(err)
-		return
+	var userIds []string
+	if err := database.NotificationCollection.Distinct(ctx, "user_id", filter).Decode(&userIds); err != nil {
+		return err
 	}
-	c.Start()
-	select {}
+
+	for _, userId := range userIds {
+		if err := workers.NotificationDigestQueue(userId); err != nil {
+			return fmt.Errorf("queuing digest for user %s: %w", userId, err)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/config"
+	"github.com/gocraft/work"
+)
+
+const idempotencyTTL = 7 * 24 * time.Hour
+
+// Idempotent skips jobs whose idempotency key has already been processed,
+// since the outbox relay may deliver the same job more than once.
+func (c *Context) Idempotent(job *work.Job, next work.NextMiddlewareFunc) error {
+	key, ok := job.Args["idempotency_key"].(string)
+	if !ok || key == "" {
+		return next()
+	}
+
+	redisKey := "jobs:idempotency:" + job.Name + ":" + key
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	done, err := config.Redis.Exists(ctx, redisKey).Result()
+	cancel()
+	if err != nil {
+		return err
+	}
+	if done > 0 {
+		return nil
+	}
+
+	if err := next(); err != nil {
+		return err
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return config.Redis.Set(ctx, redisKey, time.Now().Unix(), idempotencyTTL).Err()
+}
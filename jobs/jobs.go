@@ -4,21 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/EsanSamuel/Reddit_Clone/config"
 	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/mailer"
+	"github.com/EsanSamuel/Reddit_Clone/media"
 	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
 	"github.com/gocraft/work"
-	"github.com/resend/resend-go/v3"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type Context struct {
 	Email    string
 	UserId   string
+	User     models.User
 	PostId   string
 	Post     models.Post
 	Comments []models.Comment
@@ -31,73 +34,111 @@ func (c *Context) Log(job *work.Job, next work.NextMiddlewareFunc) error {
 
 func (c *Context) FindUser(job *work.Job, next work.NextMiddlewareFunc) error {
 	if _, ok := job.Args["user_id"]; ok {
-		c.Email = job.ArgString("email_addr")
+		if _, ok := job.Args["email_addr"]; ok {
+			c.Email = job.ArgString("email_addr")
+		}
 		c.UserId = job.ArgString("user_id")
 		if err := job.ArgError(); err != nil {
 			return err
 		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := database.UserCollection.FindOne(ctx, bson.M{"user_id": c.UserId}).Decode(&c.User); err != nil {
+			return err
+		}
+		if c.Email == "" {
+			c.Email = c.User.Email
+		}
 	}
 	return next()
 }
 
+type emailData struct {
+	FirstName string
+	URL       string
+}
+
+func (c *Context) SendVerificationEmail(job *work.Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	if c.User.EmailVerified || c.User.VerficationToken == "" {
+		return nil
+	}
+
+	data := emailData{
+		FirstName: c.User.FirstName,
+		URL:       config.AppURL() + "/verify-email?token=" + c.User.VerficationToken,
+	}
+
+	return mailer.SendTemplate(ctx, c.Email, "verification", c.User.Locale, data)
+}
+
 func (c *Context) SendWelcomeEmail(job *work.Job) error {
-	email := c.Email
-	fmt.Println(email)
-	RESEND_API_KEY := os.Getenv("RESEND_API_KEY")
-	client := resend.NewClient(RESEND_API_KEY)
-
-	params := &resend.SendEmailRequest{
-		From: "Acme <noreply@mikaelsoninitiative.org>",
-		To:   []string{email},
-		Html: `<div style="max-width: 500px; margin: 0 auto; font-family: Arial, sans-serif; background-color: #ffffff; padding: 30px; border-radius: 8px; border: 1px solid #e5e7eb;">
-
-  <h2 style="color: #111827; text-align: center; margin-bottom: 10px;">
-    Welcome to Reddit 🎉
-  </h2>
-
-  <p style="color: #374151; font-size: 15px; text-align: center;">
-    Hey there 👋
-  </p>
-
-  <p style="color: #374151; font-size: 15px; text-align: center; line-height: 1.5;">
-    We’re excited to have you on <b>Reddit</b>! Your account has been successfully created, and you’re all set to start exploring communities, sharing ideas, and joining conversations that matter to you.
-  </p>
-
-  <div style="text-align: center; margin: 30px 0;">
-    <a href=""
-       style="
-         background-color: #2563eb;
-         color: #ffffff;
-         padding: 14px 30px;
-         text-decoration: none;
-         border-radius: 6px;
-         font-weight: bold;
-         display: inline-block;
-         font-size: 16px;
-       ">
-      Get Started
-    </a>
-  </div>
-
-  <p style="color: #6b7280; font-size: 14px; text-align: center; line-height: 1.4;">
-    If you have any questions, feel free to reply to this email — we’re happy to help.
-  </p>
-
-</div>
-`,
-		Subject: "Hello from Golang",
-		Cc:      []string{"cc@example.com"},
-		Bcc:     []string{"bcc@example.com"},
-		ReplyTo: "replyto@example.com",
-	}
-
-	sent, err := client.Emails.Send(params)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	data := emailData{
+		FirstName: c.User.FirstName,
+		URL:       config.AppURL(),
+	}
+
+	return mailer.SendTemplate(ctx, c.Email, "welcome", c.User.Locale, data)
+}
+
+type digestData struct {
+	FirstName     string
+	URL           string
+	Notifications []models.Notification
+}
+
+// SendNotificationDigest emails the user's unread notifications for every
+// type they enabled email for, then marks them as emailed.
+func (c *Context) SendNotificationDigest(job *work.Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": c.UserId, "read": false, "emailed": false}
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(50)
+
+	cursor, err := database.NotificationCollection.Find(ctx, filter, findOptions)
 	if err != nil {
-		fmt.Println(err.Error())
 		return err
 	}
-	fmt.Println(sent.Id)
-	return nil
+	defer cursor.Close(ctx)
+
+	var unread []models.Notification
+	if err := cursor.All(ctx, &unread); err != nil {
+		return err
+	}
+
+	var notifications []models.Notification
+	var notificationIds []string
+	for _, notification := range unread {
+		if services.Preference(c.User, notification.Type).Email {
+			notifications = append(notifications, notification)
+			notificationIds = append(notificationIds, notification.NotificationID)
+		}
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	data := digestData{
+		FirstName:     c.User.FirstName,
+		URL:           config.AppURL() + "/notifications",
+		Notifications: notifications,
+	}
+
+	if err := mailer.SendTemplate(ctx, c.Email, "digest", c.User.Locale, data); err != nil {
+		return err
+	}
+
+	_, err = database.NotificationCollection.UpdateMany(ctx, bson.M{"notification_id": bson.M{"$in": notificationIds}}, bson.M{"$set": bson.M{"emailed": true}})
+	return err
 }
 
 func (c *Context) FindPost(job *work.Job, next work.NextMiddlewareFunc) error {
@@ -114,6 +155,9 @@ func (c *Context) FindPost(job *work.Job, next work.NextMiddlewareFunc) error {
 
 		// Fetch all Comment
 		cursor, err := database.CommentCollection.Find(ctx, bson.M{"post_id": c.PostId})
+		if err != nil {
+			return err
+		}
 		if err := cursor.All(ctx, &c.Comments); err != nil {
 			return err
 		}
@@ -128,6 +172,9 @@ func (c *Context) FindPost(job *work.Job, next work.NextMiddlewareFunc) error {
 
 func (c *Context) SendAISummary(job *work.Job) error {
 	commentsJSON, err := json.Marshal(c.Comments)
+	if err != nil {
+		return err
+	}
 	post := c.Post
 
 	prompt := fmt.Sprintf(`You are an AI assistant. I will provide you with a post and its associated comments. Summarize the content for a user in a concise and informative way. Include the following:
@@ -149,10 +196,10 @@ Comments: %s
 `, post.Title, post.Content, post.Type, post.Tags, string(commentsJSON))
 
 	summary, err := config.Ai(prompt)
-	fmt.Println(summary)
 	if err != nil {
-		fmt.Println(err.Error())
+		return err
 	}
+	fmt.Println(summary)
 	return nil
 }
 
@@ -191,7 +238,10 @@ func (c *Context) GeneratePostEmbeddings(job *work.Job) error {
 
 	fmt.Println(embeddingContent)
 
-	embeddings := config.AIEmbeddings(embeddingContent)
+	embeddings, err := config.AIEmbeddings(embeddingContent)
+	if err != nil {
+		return err
+	}
 	//fmt.Println(embeddings)
 
 	_, err = database.PostCollection.UpdateOne(
@@ -199,9 +249,30 @@ func (c *Context) GeneratePostEmbeddings(job *work.Job) error {
 		bson.M{"post_id": postId},
 		bson.M{"$set": bson.M{"embeddings": embeddings}},
 	)
-	if err != nil {
-		fmt.Println(err)
+
+	return err
+}
+
+func (c *Context) ProcessMedia(job *work.Job) error {
+	mediaId := job.ArgString("media_id")
+	if err := job.ArgError(); err != nil {
+		return err
 	}
 
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	return media.Process(ctx, mediaId)
+}
+
+func (c *Context) UnfurlLink(job *work.Job) error {
+	postId := job.ArgString("post_id")
+	if err := job.ArgError(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	return services.UnfurlPost(ctx, services.LinkFetcher, postId)
 }
@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocraft/work"
+	"github.com/gomodule/redigo/redis"
+)
+
+// Manager owns every worker pool and enqueuer so they can be shared
+// across the API and stopped together on shutdown.
+type Manager struct {
+	redisPool *redis.Pool
+
+	mu        sync.Mutex
+	pools     map[string]*work.WorkerPool
+	enqueuers map[string]*work.Enqueuer
+	started   bool
+	stop      chan struct{}
+	wake      chan struct{}
+	relayDone chan struct{}
+}
+
+func NewManager(redisPool *redis.Pool) *Manager {
+	return &Manager{
+		redisPool: redisPool,
+		pools:     make(map[string]*work.WorkerPool),
+		enqueuers: make(map[string]*work.Enqueuer),
+		stop:      make(chan struct{}),
+		wake:      make(chan struct{}, 1),
+		relayDone: make(chan struct{}),
+	}
+}
+
+// Pool returns the worker pool for a namespace, creating it on first use.
+func (m *Manager) Pool(namespace string, concurrency uint) *work.WorkerPool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if pool, ok := m.pools[namespace]; ok {
+		return pool
+	}
+
+	pool := work.NewWorkerPool(Context{}, concurrency, namespace, m.redisPool)
+	m.pools[namespace] = pool
+	return pool
+}
+
+func (m *Manager) enqueuer(namespace string) *work.Enqueuer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if enqueuer, ok := m.enqueuers[namespace]; ok {
+		return enqueuer
+	}
+
+	enqueuer := work.NewEnqueuer(namespace, m.redisPool)
+	m.enqueuers[namespace] = enqueuer
+	return enqueuer
+}
+
+// Enqueue pushes a job onto its queue. When Redis is unavailable the job
+// is written to the outbox instead, and an error is only returned if
+// neither succeeded.
+func (m *Manager) Enqueue(ctx context.Context, namespace string, jobName string, args work.Q) error {
+	_, err := m.enqueuer(namespace).Enqueue(jobName, args)
+	if err == nil {
+		return nil
+	}
+
+	fmt.Println("Error queuing", jobName, "falling back to outbox:", err.Error())
+
+	if outboxErr := WriteOutbox(ctx, namespace, jobName, "", args, err); outboxErr != nil {
+		return fmt.Errorf("enqueue %s: %w (outbox: %v)", jobName, err, outboxErr)
+	}
+
+	return nil
+}
+
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started {
+		return
+	}
+	m.started = true
+
+	for _, pool := range m.pools {
+		pool.Start()
+	}
+
+	go m.relayOutbox()
+}
+
+// Stop stops every pool, waiting for in-flight jobs to finish until the
+// context deadline is reached.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.started {
+		m.mu.Unlock()
+		return nil
+	}
+	m.started = false
+	close(m.stop)
+	pools := make([]*work.WorkerPool, 0, len(m.pools))
+	for _, pool := range m.pools {
+		pools = append(pools, pool)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for _, pool := range pools {
+			wg.Add(1)
+			go func(pool *work.WorkerPool) {
+				defer wg.Done()
+				pool.Stop()
+			}(pool)
+		}
+		wg.Wait()
+		<-m.relayDone
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers did not stop before deadline: %w", ctx.Err())
+	}
+}
+
+// Namespaces lists the namespace of every registered worker pool.
+func (m *Manager) Namespaces() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	namespaces := make([]string, 0, len(m.pools))
+	for namespace := range m.pools {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	return namespaces
+}
+
+func (m *Manager) HasNamespace(namespace string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.pools[namespace]
+	return ok
+}
+
+// Client returns a gocraft/work client for inspecting a namespace.
+func (m *Manager) Client(namespace string) *work.Client {
+	return work.NewClient(namespace, m.redisPool)
+}
+
+// PendingJobs returns up to limit jobs waiting in a queue. The work client
+// only exposes counts for pending jobs, so the queue list is read directly.
+func (m *Manager) PendingJobs(namespace string, jobName string, limit int) ([]*work.Job, error) {
+	conn := m.redisPool.Get()
+	defer conn.Close()
+
+	key := strings.TrimSuffix(namespace, ":") + ":jobs:" + jobName
+
+	values, err := redis.ByteSlices(conn.Do("LRANGE", key, 0, limit-1))
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*work.Job, 0, len(values))
+	for _, value := range values {
+		var job work.Job
+		if err := json.Unmarshal(value, &job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, &job)
+	}
+
+	return jobs, nil
+}
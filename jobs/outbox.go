@@ -0,0 +1,134 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/gocraft/work"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	outboxRelayInterval = 30 * time.Second
+	outboxLease         = time.Minute
+)
+
+// WriteOutbox records a job to be published by the relay. Pass the context
+// given by database.WithTransaction to commit it with the primary write.
+// The idempotency key travels with the job so consumers can skip
+// duplicate deliveries.
+func WriteOutbox(ctx context.Context, namespace string, jobName string, idempotencyKey string, args work.Q, cause error) error {
+	if idempotencyKey == "" {
+		idempotencyKey = bson.NewObjectID().Hex()
+	}
+
+	jobArgs := work.Q{}
+	for key, value := range args {
+		jobArgs[key] = value
+	}
+	jobArgs["idempotency_key"] = idempotencyKey
+
+	entry := models.OutboxEntry{
+		OutboxID:       bson.NewObjectID().Hex(),
+		IdempotencyKey: idempotencyKey,
+		Namespace:      namespace,
+		JobName:        jobName,
+		Args:           jobArgs,
+		Status:         "PENDING",
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if cause != nil {
+		entry.LastError = cause.Error()
+	}
+
+	_, err := database.OutboxCollection.InsertOne(ctx, entry)
+	return err
+}
+
+// NotifyOutbox wakes the relay so entries committed by a request are
+// published without waiting for the next tick.
+func (m *Manager) NotifyOutbox() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (m *Manager) relayOutbox() {
+	defer close(m.relayDone)
+
+	ticker := time.NewTicker(outboxRelayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		case <-m.wake:
+		}
+
+		if err := m.publishOutbox(); err != nil {
+			fmt.Println("Error relaying outbox:", err.Error())
+		}
+	}
+}
+
+// publishOutbox claims pending entries one at a time so several replicas can
+// relay concurrently. An entry whose claim expires before it is marked
+// published is picked up again, giving at-least-once delivery.
+func (m *Manager) publishOutbox() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	for {
+		now := time.Now()
+
+		filter := bson.M{
+			"$or": []bson.M{
+				{"status": "PENDING"},
+				{"status": "PUBLISHING", "locked_until": bson.M{"$lt": now}},
+			},
+		}
+		update := bson.M{
+			"$set": bson.M{"status": "PUBLISHING", "locked_until": now.Add(outboxLease), "updated_at": now},
+			"$inc": bson.M{"attempts": 1},
+		}
+		findOptions := options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetReturnDocument(options.After)
+
+		var entry models.OutboxEntry
+		err := database.OutboxCollection.FindOneAndUpdate(ctx, filter, update, findOptions).Decode(&entry)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := m.enqueuer(entry.Namespace).Enqueue(entry.JobName, entry.Args); err != nil {
+			_, _ = database.OutboxCollection.UpdateOne(ctx, bson.M{"outbox_id": entry.OutboxID}, bson.M{
+				"$set":   bson.M{"status": "PENDING", "last_error": err.Error(), "updated_at": time.Now()},
+				"$unset": bson.M{"locked_until": ""},
+			})
+			return err
+		}
+
+		_, err = database.OutboxCollection.UpdateOne(ctx, bson.M{"outbox_id": entry.OutboxID}, bson.M{
+			"$set":   bson.M{"status": "PUBLISHED", "published_at": time.Now(), "updated_at": time.Now()},
+			"$unset": bson.M{"locked_until": ""},
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"math"
+	"time"
+
+	"github.com/gocraft/work"
+)
+
+// RetryPolicy controls how often a job is retried and how long to wait
+// between attempts. Once MaxFails is reached the job is moved to the dead
+// queue where it can be inspected and retried from the admin API.
+type RetryPolicy struct {
+	MaxFails  uint
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxFails:  5,
+	BaseDelay: 10 * time.Second,
+	MaxDelay:  time.Hour,
+}
+
+// Backoff doubles the delay after every failure, capped at MaxDelay.
+func (p RetryPolicy) Backoff(job *work.Job) int64 {
+	fails := job.Fails
+	if fails < 1 {
+		fails = 1
+	}
+
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(fails-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	seconds := int64(time.Duration(delay) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return seconds
+}
+
+func (p RetryPolicy) Options() work.JobOptions {
+	return work.JobOptions{
+		MaxFails: p.MaxFails,
+		Backoff:  p.Backoff,
+	}
+}
@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/config"
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type Handler func(ctx context.Context) error
+
+type job struct {
+	name     string
+	spec     string
+	schedule cron.Schedule
+	timeout  time.Duration
+	handler  Handler
+}
+
+// parser accepts the same specs as cron.WithSeconds.
+var parser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// tickLookback is how late a replica can start a run and still attribute it
+// to the right tick.
+const tickLookback = time.Minute
+
+// Scheduler runs registered jobs on their cron spec. Each tick is claimed
+// in Redis so only one replica runs it, a lease keeps runs of the same job
+// from overlapping, and every run is recorded in the scheduled_jobs
+// collection.
+type Scheduler struct {
+	cron     *cron.Cron
+	instance string
+	jobs     map[string]job
+}
+
+// releaseLease only deletes the lease if this instance still holds it.
+const releaseLease = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`
+
+func New() *Scheduler {
+	hostname, _ := os.Hostname()
+
+	return &Scheduler{
+		cron:     cron.New(cron.WithParser(parser)),
+		instance: fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), bson.NewObjectID().Hex()),
+		jobs:     make(map[string]job),
+	}
+}
+
+// Register adds a job. The timeout bounds a single run and is also used as
+// the lease duration.
+func (s *Scheduler) Register(name string, spec string, timeout time.Duration, handler Handler) error {
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("scheduled job %q already registered", name)
+	}
+
+	schedule, err := parser.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("scheduled job %q: %w", name, err)
+	}
+
+	j := job{name: name, spec: spec, schedule: schedule, timeout: timeout, handler: handler}
+
+	s.cron.Schedule(schedule, cron.FuncJob(func() { s.run(j) }))
+
+	s.jobs[name] = j
+	return nil
+}
+
+func (s *Scheduler) Start() {
+	s.cron.Start()
+}
+
+// Stop prevents new runs and waits for running jobs until the context
+// deadline.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	select {
+	case <-s.cron.Stop().Done():
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduled jobs did not stop before deadline: %w", ctx.Err())
+	}
+}
+
+func (s *Scheduler) run(j job) {
+	ctx, cancel := context.WithTimeout(context.Background(), j.timeout)
+	defer cancel()
+
+	// The tick claim is never released, so a replica whose cron fires a
+	// moment later cannot run the same tick again once the first run ends
+	tick := j.tick(time.Now())
+	tickKey := fmt.Sprintf("scheduler:tick:%s:%d", j.name, tick.Unix())
+
+	claimed, err := config.Redis.SetNX(ctx, tickKey, s.instance, max(j.timeout, tickLookback)).Result()
+	if err != nil {
+		fmt.Println("Error claiming tick for", j.name, err.Error())
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	leaseKey := "scheduler:lease:" + j.name
+
+	acquired, err := config.Redis.SetNX(ctx, leaseKey, s.instance, j.timeout).Result()
+	if err != nil {
+		fmt.Println("Error acquiring lease for", j.name, err.Error())
+		return
+	}
+	if !acquired {
+		return
+	}
+	defer config.Redis.Eval(context.Background(), releaseLease, []string{leaseKey}, s.instance)
+
+	start := time.Now()
+	err = s.safeRun(ctx, j)
+	duration := time.Since(start)
+
+	outcome := "SUCCESS"
+	lastError := ""
+	if err != nil {
+		outcome = "FAILED"
+		lastError = err.Error()
+		fmt.Println("Scheduled job", j.name, "failed:", lastError)
+	}
+
+	s.record(j, start, duration, outcome, lastError)
+}
+
+// tick returns the scheduled time of the run starting at now: the latest
+// activation of the job's schedule that is not after now. It is the same on
+// every replica whose clock is close.
+func (j job) tick(now time.Time) time.Time {
+	t := j.schedule.Next(now.Add(-tickLookback))
+	if t.After(now) {
+		return now.Truncate(time.Second)
+	}
+	for next := j.schedule.Next(t); !next.After(now); next = j.schedule.Next(t) {
+		t = next
+	}
+	return t
+}
+
+func (s *Scheduler) safeRun(ctx context.Context, j job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return j.handler(ctx)
+}
+
+func (s *Scheduler) record(j job, start time.Time, duration time.Duration, outcome string, lastError string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":        j.name,
+			"spec":        j.spec,
+			"last_run_at": start,
+			"duration_ms": duration.Milliseconds(),
+			"outcome":     outcome,
+			"last_error":  lastError,
+			"instance":    s.instance,
+			"updated_at":  time.Now(),
+		},
+		"$inc": bson.M{"run_count": 1},
+	}
+
+	_, err := database.ScheduledJobCollection.UpdateOne(ctx, bson.M{"name": j.name}, update, options.UpdateOne().SetUpsert(true))
+	if err != nil {
+		fmt.Println("Error recording run for", j.name, err.Error())
+	}
+}
@@ -1,14 +1,32 @@
 package workers
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"time"
 
 	"github.com/EsanSamuel/Reddit_Clone/jobs"
+	"github.com/EsanSamuel/Reddit_Clone/models"
 	"github.com/gocraft/work"
 	"github.com/gomodule/redigo/redis"
 )
 
+const (
+	AISummaryNamespace   = "ai_summaryQueue"
+	EmailNamespace       = "emailQueue"
+	AIEmbeddingNamespace = "ai_embeddings_queue"
+	MediaNamespace       = "media_queue"
+	LinkNamespace        = "link_queue"
+)
+
+// Retry policies per job, failed jobs past MaxFails go to the dead queue
+var (
+	AISummaryRetryPolicy   = jobs.RetryPolicy{MaxFails: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	EmailRetryPolicy       = jobs.RetryPolicy{MaxFails: 8, BaseDelay: 30 * time.Second, MaxDelay: 6 * time.Hour}
+	AIEmbeddingRetryPolicy = jobs.DefaultRetryPolicy
+	MediaRetryPolicy       = jobs.RetryPolicy{MaxFails: 5, BaseDelay: 30 * time.Second, MaxDelay: 30 * time.Minute}
+	LinkRetryPolicy        = jobs.RetryPolicy{MaxFails: 4, BaseDelay: time.Minute, MaxDelay: time.Hour}
+)
+
 // Redis connection
 func NewRedisPool(addr string) *redis.Pool {
 	return &redis.Pool{
@@ -23,83 +41,126 @@ func NewRedisPool(addr string) *redis.Pool {
 
 var redisPool *redis.Pool = NewRedisPool(":6379")
 
-func AISummaryQueue(postId string) {
-	var enqueuer = work.NewEnqueuer("ai_summaryQueue", redisPool)
+var Manager *jobs.Manager = jobs.NewManager(redisPool)
 
-	_, err := enqueuer.Enqueue("send_ai_summary", work.Q{"post_id": postId})
-
-	if err != nil {
-		fmt.Println("Error queuing ai summary", err.Error())
-		log.Fatal(err)
-	}
+func AISummaryQueue(postId string) error {
+	return Manager.Enqueue(context.Background(), AISummaryNamespace, "send_ai_summary", work.Q{"post_id": postId})
 }
 
 func AISummaryWorker() {
-	worker := work.NewWorkerPool(jobs.Context{}, 10, "ai_summaryQueue", redisPool)
+	worker := Manager.Pool(AISummaryNamespace, 10)
 
 	worker.Middleware((*jobs.Context).Log)
+	worker.Middleware((*jobs.Context).Idempotent)
 	worker.Middleware((*jobs.Context).FindPost)
 
-	worker.Job("send_ai_summary", (*jobs.Context).SendAISummary)
-
-	worker.Start()
+	worker.JobWithOptions("send_ai_summary", AISummaryRetryPolicy.Options(), (*jobs.Context).SendAISummary)
 }
 
-func StopAISummaryWorker() {
-	worker := work.NewWorkerPool(jobs.Context{}, 10, "ai_summaryQueue", redisPool)
-	worker.Stop()
+func SendEmailQueue(email string, userId string) error {
+	return Manager.Enqueue(context.Background(), EmailNamespace, "send_welcome_email", work.Q{"email_addr": email, "user_id": userId})
 }
 
-func SendEmailQueue(email string, userId string) {
-	var enqueuer = work.NewEnqueuer("emailQueue", redisPool)
+// WelcomeEmailOutbox records the welcome email in the outbox as part of the
+// caller's transaction.
+func WelcomeEmailOutbox(ctx context.Context, email string, userId string) error {
+	return jobs.WriteOutbox(ctx, EmailNamespace, "send_welcome_email", "send_welcome_email:"+userId, work.Q{"email_addr": email, "user_id": userId}, nil)
+}
 
-	_, err := enqueuer.Enqueue("send_welcome_email", work.Q{"email_addr": email, "user_id": userId})
+// VerificationEmailOutbox records the verification email in the outbox as
+// part of the caller's transaction.
+func VerificationEmailOutbox(ctx context.Context, email string, userId string) error {
+	return jobs.WriteOutbox(ctx, EmailNamespace, "send_verification_email", "send_verification_email:"+userId, work.Q{"email_addr": email, "user_id": userId}, nil)
+}
 
-	if err != nil {
-		fmt.Println("Error queuing email", err.Error())
-		log.Fatal(err)
-	}
+func NotificationDigestQueue(userId string) error {
+	return Manager.Enqueue(context.Background(), EmailNamespace, "send_notification_digest", work.Q{"user_id": userId})
 }
 
 func EmailWorker() {
-	worker := work.NewWorkerPool(jobs.Context{}, 10, "emailQueue", redisPool)
+	worker := Manager.Pool(EmailNamespace, 10)
 
 	worker.Middleware((*jobs.Context).Log)
+	worker.Middleware((*jobs.Context).Idempotent)
 	worker.Middleware((*jobs.Context).FindUser)
 
-	worker.Job("send_welcome_email", (*jobs.Context).SendWelcomeEmail)
+	worker.JobWithOptions("send_welcome_email", EmailRetryPolicy.Options(), (*jobs.Context).SendWelcomeEmail)
+	worker.JobWithOptions("send_verification_email", EmailRetryPolicy.Options(), (*jobs.Context).SendVerificationEmail)
+	worker.JobWithOptions("send_notification_digest", EmailRetryPolicy.Options(), (*jobs.Context).SendNotificationDigest)
+}
+
+func AIEmbeddingQueue(postId string) error {
+	return Manager.Enqueue(context.Background(), AIEmbeddingNamespace, "generate_ai_embeddings", work.Q{"post_id": postId})
+}
+
+// AIEmbeddingOutbox records the embedding job in the outbox as part of the
+// caller's transaction.
+func AIEmbeddingOutbox(ctx context.Context, postId string) error {
+	return jobs.WriteOutbox(ctx, AIEmbeddingNamespace, "generate_ai_embeddings", "generate_ai_embeddings:"+postId, work.Q{"post_id": postId}, nil)
+}
+
+func AIEmbeddingWorker() {
+	worker := Manager.Pool(AIEmbeddingNamespace, 10)
+
+	worker.Middleware((*jobs.Context).Log)
+	worker.Middleware((*jobs.Context).Idempotent)
+	worker.Middleware((*jobs.Context).FindPost)
 
-	worker.Start()
+	worker.JobWithOptions("generate_ai_embeddings", AIEmbeddingRetryPolicy.Options(), (*jobs.Context).GeneratePostEmbeddings)
 }
 
-func StopEmailWorker() {
-	worker := work.NewWorkerPool(jobs.Context{}, 10, "emailQueue", redisPool)
-	worker.Stop()
+func MediaProcessingQueue(mediaId string) error {
+	return Manager.Enqueue(context.Background(), MediaNamespace, "process_media", work.Q{"media_id": mediaId})
 }
 
-func AIEmbeddingQueue(postId string) {
-	var enqueuer = work.NewEnqueuer("ai_embeddings_queue", redisPool)
+func MediaWorker() {
+	worker := Manager.Pool(MediaNamespace, 4)
+
+	worker.Middleware((*jobs.Context).Log)
+	worker.Middleware((*jobs.Context).Idempotent)
 
-	_, err := enqueuer.Enqueue("generate_ai_embeddings", work.Q{"post_id": postId})
+	worker.JobWithOptions("process_media", MediaRetryPolicy.Options(), (*jobs.Context).ProcessMedia)
+}
 
-	if err != nil {
-		fmt.Println("Error queuing ai embedding", err.Error())
-		log.Fatal(err)
+// LinkPreviewOutbox records the preview job for a link post in the outbox
+// as part of the caller's transaction.
+func LinkPreviewOutbox(ctx context.Context, postId string) error {
+	return jobs.WriteOutbox(ctx, LinkNamespace, "unfurl_link", "unfurl_link:"+postId, work.Q{"post_id": postId}, nil)
+}
+
+// PostPublishedOutbox records the jobs a post needs once it goes live: its
+// embeddings and, for link posts, the preview.
+func PostPublishedOutbox(ctx context.Context, post models.Post) error {
+	if post.Type == models.LINK_POST {
+		if err := LinkPreviewOutbox(ctx, post.PostID); err != nil {
+			return err
+		}
 	}
+
+	return AIEmbeddingOutbox(ctx, post.PostID)
 }
 
-func AIEmbeddingWorker() {
-	worker := work.NewWorkerPool(jobs.Context{}, 10, "ai_embeddings_queue", redisPool)
+func LinkWorker() {
+	worker := Manager.Pool(LinkNamespace, 4)
 
 	worker.Middleware((*jobs.Context).Log)
-	worker.Middleware((*jobs.Context).FindPost)
+	worker.Middleware((*jobs.Context).Idempotent)
+
+	worker.JobWithOptions("unfurl_link", LinkRetryPolicy.Options(), (*jobs.Context).UnfurlLink)
+}
 
-	worker.Job("generate_ai_embeddings", (*jobs.Context).GeneratePostEmbeddings)
+// Start registers every job handler and starts the shared worker pools.
+func Start() {
+	EmailWorker()
+	AISummaryWorker()
+	AIEmbeddingWorker()
+	MediaWorker()
+	LinkWorker()
 
-	worker.Start()
+	Manager.Start()
 }
 
-func StopAIEmbeddingWorker() {
-	worker := work.NewWorkerPool(jobs.Context{}, 10, "ai_embeddings_queue", redisPool)
-	worker.Stop()
+// Stop drains in-flight jobs, giving up once the context deadline passes.
+func Stop(ctx context.Context) error {
+	return Manager.Stop(ctx)
 }
@@ -0,0 +1,174 @@
+package links
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrBlockedAddress = errors.New("link resolves to a private or reserved address")
+	ErrNotHTML        = errors.New("link is not an HTML page")
+	ErrBadStatus      = errors.New("link returned an error status")
+)
+
+const (
+	// MaxBodySize is how much of a page is read looking for metadata.
+	MaxBodySize  = 1 << 20
+	maxRedirects = 5
+	userAgent    = "Reddit_Clone-LinkPreview/1.0"
+)
+
+// StatusError is returned for a response outside 2xx. It matches
+// ErrBadStatus with errors.Is.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %d", ErrBadStatus, e.Code)
+}
+
+func (e *StatusError) Unwrap() error {
+	return ErrBadStatus
+}
+
+// Temporary reports whether the site may answer later: it is rate limiting
+// us or failing on its side.
+func (e *StatusError) Temporary() bool {
+	return e.Code == http.StatusTooManyRequests || e.Code >= 500
+}
+
+// Fetcher downloads pages for previews. The default client refuses to
+// connect to private, loopback and link-local addresses, checked on the
+// resolved IP at dial time so DNS rebinding cannot get around it.
+type Fetcher struct {
+	Client *http.Client
+}
+
+// NewFetcher returns a Fetcher with the SSRF protections and timeouts.
+func NewFetcher() *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !Public(ip) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return NewFetcherWithTransport(transport)
+}
+
+// NewFetcherWithTransport is NewFetcher over another transport, such as one
+// that can reach a local test server.
+func NewFetcherWithTransport(transport http.RoundTripper) *Fetcher {
+	return &Fetcher{
+		Client: &http.Client{
+			Transport: transport,
+			Timeout:   10 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return errors.New("too many redirects")
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return ErrInvalidURL
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Public reports whether ip is a globally routable unicast address.
+func Public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return false
+	}
+
+	// Shared address space (carrier-grade NAT) and benchmarking ranges
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Fetch downloads a page and extracts its preview. Only the first
+// MaxBodySize bytes of HTML responses are read.
+func (f *Fetcher) Fetch(ctx context.Context, link string) (Preview, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return Preview{}, ErrInvalidURL
+	}
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	response, err := f.Client.Do(request)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return Preview{}, &StatusError{Code: response.StatusCode}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Preview{}, fmt.Errorf("%w: %s", ErrNotHTML, mediaType)
+	}
+
+	preview, err := ParsePreview(io.LimitReader(response.Body, MaxBodySize), response.Request.URL)
+	if err != nil {
+		return Preview{}, err
+	}
+	return preview, nil
+}
+
+// resolve makes a possibly relative reference absolute against base,
+// keeping only http and https results.
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+
+	parsed, err := base.Parse(ref)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	return parsed.String()
+}
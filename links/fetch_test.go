@@ -0,0 +1,241 @@
+package links
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+// testFetcher reaches httptest servers, which listen on loopback and are
+// refused by NewFetcher.
+func testFetcher() *Fetcher {
+	return NewFetcherWithTransport(http.DefaultTransport)
+}
+
+func TestFetchOpenGraph(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head>
+			<title>Page title</title>
+			<meta property="og:title" content="  OG   title ">
+			<meta property="og:description" content="OG description">
+			<meta property="og:site_name" content="Example">
+			<meta property="og:image" content="/images/card.png">
+			<meta name="twitter:title" content="Twitter title">
+		</head><body><meta property="og:title" content="Ignored"></body></html>`)
+	}))
+	defer server.Close()
+
+	preview, err := testFetcher().Fetch(context.Background(), server.URL+"/articles/1")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	want := Preview{
+		Title:       "OG title",
+		Description: "OG description",
+		SiteName:    "Example",
+		ImageURL:    server.URL + "/images/card.png",
+	}
+	if preview != want {
+		t.Errorf("preview = %+v, want %+v", preview, want)
+	}
+}
+
+func TestFetchTwitterFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head>
+			<meta name="twitter:title" content="Twitter title">
+			<meta name="twitter:description" content="Twitter description">
+			<meta name="twitter:image" content="../thumb.jpg">
+		</head></html>`)
+	}))
+	defer server.Close()
+
+	preview, err := testFetcher().Fetch(context.Background(), server.URL+"/a/b/page")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	want := Preview{
+		Title:       "Twitter title",
+		Description: "Twitter description",
+		ImageURL:    server.URL + "/a/thumb.jpg",
+	}
+	if preview != want {
+		t.Errorf("preview = %+v, want %+v", preview, want)
+	}
+}
+
+func TestFetchTitleFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Plain title</title><meta name="description" content="Plain description"></head></html>`)
+	}))
+	defer server.Close()
+
+	preview, err := testFetcher().Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if preview.Title != "Plain title" || preview.Description != "Plain description" {
+		t.Errorf("preview = %+v", preview)
+	}
+}
+
+func TestFetchRejectsUnsafeImageURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><meta property="og:image" content="javascript:alert(1)"></head></html>`)
+	}))
+	defer server.Close()
+
+	preview, err := testFetcher().Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if preview.ImageURL != "" {
+		t.Errorf("ImageURL = %q, want empty", preview.ImageURL)
+	}
+}
+
+func TestFetchNotHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		fmt.Fprint(w, "%PDF-1.7")
+	}))
+	defer server.Close()
+
+	_, err := testFetcher().Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrNotHTML) {
+		t.Errorf("err = %v, want ErrNotHTML", err)
+	}
+}
+
+func TestFetchBodySizeCap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Early</title><!--`)
+		fmt.Fprint(w, strings.Repeat("x", MaxBodySize))
+		fmt.Fprint(w, `--><meta property="og:title" content="Late"></head></html>`)
+	}))
+	defer server.Close()
+
+	preview, err := testFetcher().Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if preview.Title != "Early" {
+		t.Errorf("Title = %q, want the title before the cap", preview.Title)
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hops/{n}", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscan(r.PathValue("n"), &n)
+		if n == 0 {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><title>Landed</title></head></html>`)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/hops/%d", n-1), http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	preview, err := testFetcher().Fetch(context.Background(), fmt.Sprintf("%s/hops/%d", server.URL, maxRedirects))
+	if err != nil {
+		t.Fatalf("Fetch within the redirect limit: %v", err)
+	}
+	if preview.Title != "Landed" {
+		t.Errorf("Title = %q, want Landed", preview.Title)
+	}
+
+	_, err = testFetcher().Fetch(context.Background(), fmt.Sprintf("%s/hops/%d", server.URL, maxRedirects+1))
+	if err == nil || !strings.Contains(err.Error(), "too many redirects") {
+		t.Errorf("err = %v, want too many redirects", err)
+	}
+}
+
+func TestFetchStatus(t *testing.T) {
+	tests := []struct {
+		code      int
+		temporary bool
+	}{
+		{http.StatusNotFound, false},
+		{http.StatusForbidden, false},
+		{http.StatusGone, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusServiceUnavailable, true},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.code)
+		}))
+
+		_, err := testFetcher().Fetch(context.Background(), server.URL)
+		server.Close()
+
+		if !errors.Is(err, ErrBadStatus) {
+			t.Errorf("%d: err = %v, want ErrBadStatus", test.code, err)
+			continue
+		}
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.Temporary() != test.temporary {
+			t.Errorf("%d: Temporary() = %v, want %v", test.code, statusErr != nil && statusErr.Temporary(), test.temporary)
+		}
+	}
+}
+
+func TestNewFetcherRefusesLoopback(t *testing.T) {
+	reached := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	_, err := NewFetcher().Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("err = %v, want ErrBlockedAddress", err)
+	}
+	if reached {
+		t.Error("the loopback server was reached")
+	}
+}
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, test := range tests {
+		if got := Public(netip.MustParseAddr(test.ip)); got != test.public {
+			t.Errorf("Public(%s) = %v, want %v", test.ip, got, test.public)
+		}
+	}
+}
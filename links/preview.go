@@ -0,0 +1,109 @@
+package links
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Preview is the metadata shown on a link post's card.
+type Preview struct {
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+const maxFieldLength = 500
+
+// ParsePreview reads OpenGraph and Twitter card tags from the page head,
+// falling back to <title> and the description meta tag. base resolves
+// relative image URLs.
+func ParsePreview(r io.Reader, base *url.URL) (Preview, error) {
+	meta := map[string]string{}
+	title := ""
+
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if tokenizer.Err() == io.EOF {
+				return buildPreview(meta, title, base), nil
+			}
+			return Preview{}, tokenizer.Err()
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "meta":
+				name, content := "", ""
+				for _, attr := range token.Attr {
+					switch strings.ToLower(attr.Key) {
+					case "property", "name":
+						name = strings.ToLower(strings.TrimSpace(attr.Val))
+					case "content":
+						content = attr.Val
+					}
+				}
+				if _, seen := meta[name]; name != "" && !seen {
+					meta[name] = content
+				}
+			case "title":
+				inTitle = title == ""
+			case "body":
+				// Metadata only lives in the head
+				return buildPreview(meta, title, base), nil
+			}
+
+		case html.TextToken:
+			if inTitle {
+				title += string(tokenizer.Text())
+			}
+
+		case html.EndTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = false
+			case "head":
+				return buildPreview(meta, title, base), nil
+			}
+		}
+	}
+}
+
+func buildPreview(meta map[string]string, title string, base *url.URL) Preview {
+	first := func(names ...string) string {
+		for _, name := range names {
+			if value := clean(meta[name]); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	preview := Preview{
+		Title:       first("og:title", "twitter:title"),
+		Description: first("og:description", "twitter:description", "description"),
+		SiteName:    first("og:site_name"),
+		ImageURL:    resolve(base, first("og:image:secure_url", "og:image", "og:image:url", "twitter:image", "twitter:image:src")),
+	}
+	if preview.Title == "" {
+		preview.Title = clean(title)
+	}
+
+	return preview
+}
+
+// clean collapses whitespace and caps the length of a metadata value.
+func clean(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if runes := []rune(value); len(runes) > maxFieldLength {
+		value = string(runes[:maxFieldLength])
+	}
+	return value
+}
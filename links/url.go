@@ -0,0 +1,89 @@
+package links
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidURL = errors.New("link must be an absolute http or https URL")
+
+// trackingParams are query parameters that only identify where a click came
+// from. They are dropped so the same page shares one canonical URL.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"ref_src": true,
+}
+
+// Normalize returns the canonical form of a link and its domain. The scheme
+// and host are lowercased, default ports, fragments and tracking parameters
+// are removed and the remaining query is sorted.
+func Normalize(raw string) (string, string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", "", ErrInvalidURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", "", ErrInvalidURL
+	}
+	if parsed.User != nil || parsed.Hostname() == "" {
+		return "", "", ErrInvalidURL
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	port := parsed.Port()
+	if (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	parsed.Host = host
+	if port != "" {
+		parsed.Host = net.JoinHostPort(host, port)
+	}
+
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+
+	query := parsed.Query()
+	for name := range query {
+		if strings.HasPrefix(strings.ToLower(name), "utm_") || trackingParams[strings.ToLower(name)] {
+			query.Del(name)
+		}
+	}
+	parsed.RawQuery = encodeSorted(query)
+
+	return parsed.String(), Domain(host), nil
+}
+
+// Domain is the host a link is listed under, without a leading www.
+func Domain(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+func encodeSorted(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// FileMailer writes every message to an .eml file so emails can be opened
+// locally without any network access.
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{dir: dir}
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	raw, err := buildMIME(message)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(m.dir, bson.NewObjectID().Hex()+".eml"), raw, 0o644)
+}
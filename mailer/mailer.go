@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer delivers a rendered message. Drivers are selected with MAIL_DRIVER.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+const defaultFrom = "Acme <noreply@mikaelsoninitiative.org>"
+
+var (
+	defaultMailer Mailer
+	defaultOnce   sync.Once
+)
+
+// Default returns the mailer configured by the environment, created on
+// first use.
+func Default() Mailer {
+	defaultOnce.Do(func() {
+		mailer, err := FromEnv()
+		if err != nil {
+			fmt.Println("Error configuring mailer, using memory driver:", err.Error())
+			mailer = NewMemoryMailer()
+		}
+		defaultMailer = mailer
+	})
+	return defaultMailer
+}
+
+// FromEnv builds a mailer from MAIL_DRIVER: resend, smtp, file or memory.
+// Without a driver it uses resend when RESEND_API_KEY is set and writes to
+// the filesystem otherwise, so local runs never need the network.
+func FromEnv() (Mailer, error) {
+	driver := strings.ToLower(os.Getenv("MAIL_DRIVER"))
+	if driver == "" {
+		driver = "file"
+		if os.Getenv("RESEND_API_KEY") != "" {
+			driver = "resend"
+		}
+	}
+
+	switch driver {
+	case "resend":
+		return NewResendMailer(os.Getenv("RESEND_API_KEY")), nil
+	case "smtp":
+		return NewSMTPMailer(os.Getenv("SMTP_HOST"), os.Getenv("SMTP_PORT"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD")), nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "tmp/mail"
+		}
+		return NewFileMailer(dir), nil
+	case "memory":
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", driver)
+	}
+}
+
+func From() string {
+	if from := os.Getenv("MAIL_FROM"); from != "" {
+		return from
+	}
+	return defaultFrom
+}
+
+// SendTemplate renders a template for the locale and sends it.
+func SendTemplate(ctx context.Context, to string, name string, locale string, data any) error {
+	subject, html, text, err := Render(name, locale, data)
+	if err != nil {
+		return err
+	}
+
+	return Default().Send(ctx, Message{
+		From:    From(),
+		To:      []string{to},
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+	})
+}
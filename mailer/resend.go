@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/resend/resend-go/v3"
+)
+
+type ResendMailer struct {
+	client *resend.Client
+}
+
+func NewResendMailer(apiKey string) *ResendMailer {
+	return &ResendMailer{client: resend.NewClient(apiKey)}
+}
+
+func (m *ResendMailer) Send(ctx context.Context, message Message) error {
+	params := &resend.SendEmailRequest{
+		From:    message.From,
+		To:      message.To,
+		Subject: message.Subject,
+		Html:    message.HTML,
+		Text:    message.Text,
+	}
+
+	_, err := m.client.Emails.SendWithContext(ctx, params)
+	return err
+}
@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/mail"
+	"net/smtp"
+)
+
+// SMTPMailer delivers through any SMTP server, such as a local MailHog or
+// Mailpit instance during development.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(host string, port string, username string, password string) *SMTPMailer {
+	if host == "" {
+		host = "localhost"
+	}
+	if port == "" {
+		port = "1025"
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{addr: net.JoinHostPort(host, port), auth: auth}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	raw, err := buildMIME(message)
+	if err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(message.From)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, from.Address, message.To, raw)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
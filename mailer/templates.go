@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+const defaultLocale = "en"
+
+// Render executes templates/<locale>/<name>.html and <name>.txt, falling back
+// to English when the locale has no variant. The subject is the "subject"
+// block of the text template.
+func Render(name string, locale string, data any) (string, string, string, error) {
+	locale = normalizeLocale(locale)
+	if _, err := templateFS.Open("templates/" + locale + "/" + name + ".html"); err != nil {
+		locale = defaultLocale
+	}
+
+	base := "templates/" + locale + "/" + name
+
+	htmlTemplate, err := htmltemplate.ParseFS(templateFS, base+".html")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	textTemplate, err := texttemplate.ParseFS(templateFS, base+".txt")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	var subject, html, text bytes.Buffer
+
+	if err := textTemplate.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", "", err
+	}
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return "", "", "", err
+	}
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return "", "", "", err
+	}
+
+	return strings.TrimSpace(subject.String()), html.String(), strings.TrimSpace(text.String()), nil
+}
+
+// normalizeLocale turns values like "es-MX" into "es".
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		locale = locale[:i]
+	}
+	if locale == "" {
+		return defaultLocale
+	}
+	return locale
+}
@@ -1,35 +1,73 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/EsanSamuel/Reddit_Clone/config"
+	"github.com/EsanSamuel/Reddit_Clone/jobs/cron"
+	"github.com/EsanSamuel/Reddit_Clone/jobs/scheduler"
 	"github.com/EsanSamuel/Reddit_Clone/jobs/workers"
+	"github.com/EsanSamuel/Reddit_Clone/migrations"
 	"github.com/EsanSamuel/Reddit_Clone/routes"
+	"github.com/EsanSamuel/Reddit_Clone/storage"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(os.Args[2:]); err != nil {
+			fmt.Println("Error running migrations:", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	// The unique indexes are what keep duplicates out, so the server does
+	// not start without them
+	if config.MigrateOnStart() {
+		if err := migrate(nil); err != nil {
+			fmt.Println("Error running migrations:", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if err := storage.Init(); err != nil {
+		fmt.Println("Error configuring storage:", err.Error())
+		os.Exit(1)
+	}
+
 	r := gin.Default()
 	//config.InitLogger()
 
-	go workers.EmailWorker()
-	go workers.AISummaryWorker()
-	go workers.AIEmbeddingWorker()
+	workers.Start()
+
+	jobScheduler := scheduler.New()
+	if err := cron.RegisterJobs(jobScheduler); err != nil {
+		fmt.Println("Error registering scheduled jobs:", err.Error())
+	}
+	jobScheduler.Start()
 
 	r.GET("/hello", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "Welcome to reddit_clone api"})
 	})
 	routes.UnProtectedRoutes(r)
-	//routes.ProtectedRoutes(r)
-	//cron.CronJob()
+	routes.ProtectedRoutes(r)
+
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: r,
+	}
 
 	go func() {
-		if err := r.Run(":8080"); err != nil {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			fmt.Println("Error starting server")
 		}
 	}()
@@ -39,7 +77,46 @@ func main() {
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 	<-signalChan
 
-	workers.StopEmailWorker()
-	workers.StopAISummaryWorker()
-	workers.StopAIEmbeddingWorker()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		fmt.Println("Error shutting down server:", err.Error())
+	}
+
+	if err := jobScheduler.Stop(ctx); err != nil {
+		fmt.Println("Error stopping scheduler:", err.Error())
+	}
+
+	if err := workers.Stop(ctx); err != nil {
+		fmt.Println("Error stopping workers:", err.Error())
+	}
+}
+
+// migrate applies pending migrations, or with "status" lists every
+// migration and when it was applied.
+func migrate(args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if len(args) > 0 && args[0] == "status" {
+		statuses, err := migrations.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%4d  %-25s  %s\n", status.Version, appliedAt, status.Description)
+		}
+		return nil
+	}
+
+	versions, err := migrations.Run(ctx)
+	for _, version := range versions {
+		fmt.Println("Applied migration", version)
+	}
+	return err
 }
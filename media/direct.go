@@ -0,0 +1,385 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	// PartSize is the chunk size for multipart uploads. Each part can be
+	// retried on its own, which is what makes the upload resumable.
+	PartSize = 8 << 20
+
+	// UploadURLTTL is how long a client has to send a file or a part.
+	UploadURLTTL = time.Hour
+
+	// UnattachedTTL is how long an upload may stay unconfirmed or
+	// unattached to a post before it is swept.
+	UnattachedTTL = 24 * time.Hour
+)
+
+var (
+	ErrUploadNotFound   = errors.New("upload not found")
+	ErrUploadMissing    = errors.New("file was not uploaded")
+	ErrTypeMismatch     = errors.New("file does not match the declared content type")
+	ErrChecksumMismatch = errors.New("file does not match the given sha256")
+	ErrNotMultipart     = errors.New("upload is not a multipart upload")
+	ErrInvalidPart      = errors.New("invalid part number")
+)
+
+// partCount is how many PartSize parts a file of size is split into.
+func partCount(size int64) int {
+	return int((size + PartSize - 1) / PartSize)
+}
+
+// CreateUploadSlot records a PENDING media document and returns the
+// presigned URLs the client uploads the file to.
+func CreateUploadSlot(ctx context.Context, ownerId string, dto models.UploadSlotDTO) (models.UploadSlot, error) {
+	fileType, err := Check(dto.ContentType, dto.Size, PurposePost)
+	if err != nil {
+		return models.UploadSlot{}, err
+	}
+
+	direct, err := storage.Direct()
+	if err != nil {
+		return models.UploadSlot{}, err
+	}
+
+	mediaId := bson.NewObjectID().Hex()
+	media := models.Media{
+		MediaID:     mediaId,
+		OwnerID:     ownerId,
+		Purpose:     PurposePost,
+		Kind:        fileType.Kind,
+		ContentType: dto.ContentType,
+		Key:         fmt.Sprintf("uploads/%s/%s%s", ownerId, mediaId, fileType.Ext),
+		Size:        dto.Size,
+		Variants:    []models.MediaVariant{},
+		Status:      models.MEDIA_PENDING,
+		Direct:      true,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	slot := models.UploadSlot{
+		Method:    http.MethodPut,
+		ExpiresAt: time.Now().Add(UploadURLTTL),
+	}
+
+	// Multipart needs more than one part, S3 rejects small non-final parts
+	if dto.Multipart && dto.Size > PartSize {
+		media.UploadID, err = direct.CreateMultipart(ctx, media.Key, dto.ContentType)
+		if err != nil {
+			return models.UploadSlot{}, err
+		}
+
+		partNumbers := make([]int, partCount(dto.Size))
+		for i := range partNumbers {
+			partNumbers[i] = i + 1
+		}
+
+		slot.PartSize = PartSize
+		slot.Parts, err = signParts(direct, media, partNumbers)
+		if err != nil {
+			return models.UploadSlot{}, err
+		}
+	} else {
+		slot.URL, err = direct.SignedPutURL(media.Key, dto.ContentType, dto.Size, UploadURLTTL)
+		if err != nil {
+			return models.UploadSlot{}, err
+		}
+		slot.Headers = map[string]string{"Content-Type": dto.ContentType}
+	}
+
+	if _, err := database.MediaCollection.InsertOne(ctx, media); err != nil {
+		return models.UploadSlot{}, err
+	}
+
+	slot.Media = media
+	return slot, nil
+}
+
+func signParts(direct storage.DirectUploader, media models.Media, partNumbers []int) ([]models.UploadPartURL, error) {
+	parts := make([]models.UploadPartURL, 0, len(partNumbers))
+	for _, partNumber := range partNumbers {
+		if partNumber < 1 || partNumber > partCount(media.Size) {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidPart, partNumber)
+		}
+
+		url, err := direct.SignedPartURL(media.Key, media.UploadID, partNumber, PartSize, UploadURLTTL)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, models.UploadPartURL{PartNumber: partNumber, URL: url})
+	}
+	return parts, nil
+}
+
+func findPending(ctx context.Context, ownerId string, mediaId string) (models.Media, error) {
+	var media models.Media
+	err := database.MediaCollection.FindOne(ctx, bson.M{
+		"media_id": mediaId,
+		"owner_id": ownerId,
+		"status":   models.MEDIA_PENDING,
+	}).Decode(&media)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return media, ErrUploadNotFound
+	}
+	return media, err
+}
+
+// SignUploadParts issues fresh URLs for parts of a multipart upload, so an
+// interrupted upload can resume once the first URLs have expired.
+func SignUploadParts(ctx context.Context, ownerId string, mediaId string, partNumbers []int) ([]models.UploadPartURL, error) {
+	media, err := findPending(ctx, ownerId, mediaId)
+	if err != nil {
+		return nil, err
+	}
+	if media.UploadID == "" {
+		return nil, ErrNotMultipart
+	}
+
+	direct, err := storage.Direct()
+	if err != nil {
+		return nil, err
+	}
+
+	return signParts(direct, media, partNumbers)
+}
+
+// ConfirmUpload checks the uploaded object against the slot: its type is
+// sniffed from the stored bytes and its size and hash are measured. Files
+// that fail are deleted and the media marked FAILED. Images are left
+// PROCESSING for Process, which also strips their metadata.
+func ConfirmUpload(ctx context.Context, ownerId string, mediaId string, dto models.ConfirmUploadDTO) (models.Media, error) {
+	media, err := findPending(ctx, ownerId, mediaId)
+	if err != nil {
+		return models.Media{}, err
+	}
+
+	direct, err := storage.Direct()
+	if err != nil {
+		return models.Media{}, err
+	}
+
+	if media.UploadID != "" {
+		parts := make([]storage.Part, 0, len(dto.Parts))
+		for _, part := range dto.Parts {
+			parts = append(parts, storage.Part{PartNumber: part.PartNumber, ETag: part.ETag})
+		}
+		if err := direct.CompleteMultipart(ctx, media.Key, media.UploadID, parts); err != nil {
+			return models.Media{}, fmt.Errorf("%w: %v", ErrUploadMissing, err)
+		}
+	}
+
+	contentType, size, hash, err := inspect(ctx, media.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return models.Media{}, ErrUploadMissing
+	}
+	if err != nil {
+		return models.Media{}, err
+	}
+
+	if err := verify(media, contentType, size, hash, dto.SHA256); err != nil {
+		if deleteErr := storage.Default().Delete(ctx, media.Key); deleteErr != nil {
+			fmt.Println("Error deleting rejected upload:", deleteErr.Error())
+		}
+		if markErr := markFailed(ctx, mediaId, err); markErr != nil {
+			return models.Media{}, markErr
+		}
+		return models.Media{}, err
+	}
+
+	media.Size = size
+	media.Hash = hash
+	media.UploadID = ""
+	media.Status = models.MEDIA_READY
+	if media.Kind == KindImage {
+		media.Status = models.MEDIA_PROCESSING
+	}
+	media.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"size":       media.Size,
+			"hash":       media.Hash,
+			"status":     media.Status,
+			"updated_at": media.UpdatedAt,
+		},
+		"$unset": bson.M{"upload_id": ""},
+	}
+	if _, err := database.MediaCollection.UpdateOne(ctx, bson.M{"media_id": mediaId}, update); err != nil {
+		return models.Media{}, err
+	}
+
+	Sign(&media)
+	return media, nil
+}
+
+// inspect streams an object once to sniff its type, size and hash, without
+// holding large videos in memory.
+func inspect(ctx context.Context, key string) (string, int64, string, error) {
+	body, err := storage.Default().Get(ctx, key)
+	if err != nil {
+		return "", 0, "", err
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	reader := io.TeeReader(io.LimitReader(body, MaxSize(PurposePost)+1), hash)
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", 0, "", err
+	}
+
+	rest, err := io.Copy(io.Discard, reader)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	contentType := ""
+	if n > 0 {
+		contentType = http.DetectContentType(head[:n])
+	}
+	return contentType, int64(n) + rest, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func verify(media models.Media, contentType string, size int64, hash string, checksum string) error {
+	if _, err := Check(contentType, size, media.Purpose); err != nil {
+		return err
+	}
+	if contentType != media.ContentType {
+		return fmt.Errorf("%w: got %s", ErrTypeMismatch, contentType)
+	}
+	if checksum != "" && !strings.EqualFold(checksum, hash) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// stripDirect removes metadata from a directly uploaded image, which never
+// passed through Upload, and stores the cleaned copy in its place.
+func stripDirect(ctx context.Context, media models.Media, data []byte) ([]byte, error) {
+	stripped, err := StripMetadata(media.ContentType, data)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(stripped, data) {
+		return data, nil
+	}
+
+	if err := storage.Default().Put(ctx, media.Key, bytes.NewReader(stripped), media.ContentType); err != nil {
+		return nil, err
+	}
+	return stripped, nil
+}
+
+// AbortUpload cancels a PENDING upload and deletes whatever was sent.
+func AbortUpload(ctx context.Context, ownerId string, mediaId string) error {
+	media, err := findPending(ctx, ownerId, mediaId)
+	if err != nil {
+		return err
+	}
+
+	if err := discard(ctx, media); err != nil {
+		return err
+	}
+
+	_, err = database.MediaCollection.DeleteOne(ctx, bson.M{"media_id": mediaId})
+	return err
+}
+
+// discard deletes the stored objects of a media document. Content-addressed
+// files are kept while another document still uses the same hash.
+func discard(ctx context.Context, media models.Media) error {
+	store := storage.Default()
+
+	if media.UploadID != "" {
+		if direct, err := storage.Direct(); err == nil {
+			if err := direct.AbortMultipart(ctx, media.Key, media.UploadID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !media.Direct && media.Hash != "" {
+		shared, err := database.MediaCollection.CountDocuments(ctx, bson.M{
+			"hash":     media.Hash,
+			"purpose":  media.Purpose,
+			"direct":   bson.M{"$ne": true},
+			"media_id": bson.M{"$ne": media.MediaID},
+		})
+		if err != nil {
+			return err
+		}
+		if shared > 0 {
+			return nil
+		}
+	}
+
+	keys := []string{media.Key}
+	for _, variant := range media.Variants {
+		keys = append(keys, variant.Key)
+	}
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CleanupUnattached deletes post uploads that were never confirmed or never
+// attached to a post within UnattachedTTL, along with their files.
+func CleanupUnattached(ctx context.Context) error {
+	filter := bson.M{
+		"purpose":    PurposePost,
+		"post_id":    bson.M{"$in": []any{nil, ""}},
+		"created_at": bson.M{"$lt": time.Now().Add(-UnattachedTTL)},
+	}
+
+	cursor, err := database.MediaCollection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var stale []models.Media
+	if err := cursor.All(ctx, &stale); err != nil {
+		return err
+	}
+
+	for _, media := range stale {
+		// Delete the document first so a concurrent dedupe cannot reuse it
+		result, err := database.MediaCollection.DeleteOne(ctx, bson.M{"media_id": media.MediaID, "post_id": bson.M{"$in": []any{nil, ""}}})
+		if err != nil {
+			return err
+		}
+		if result.DeletedCount == 0 {
+			continue
+		}
+
+		if err := discard(ctx, media); err != nil {
+			return fmt.Errorf("deleting files for media %s: %w", media.MediaID, err)
+		}
+	}
+
+	return nil
+}
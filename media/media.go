@@ -0,0 +1,348 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"mime/multipart"
+	"slices"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrNotAttachable = errors.New("media not found or already attached")
+
+// objectKey is the content-addressed storage key for a file, optionally for
+// one of its variants.
+func objectKey(hash string, variant string, ext string) string {
+	if variant != "" {
+		return fmt.Sprintf("media/%s/%s_%s%s", hash[:2], hash, variant, ext)
+	}
+	return fmt.Sprintf("media/%s/%s%s", hash[:2], hash, ext)
+}
+
+// UploadFile is Upload for a file from a multipart form.
+func UploadFile(ctx context.Context, ownerId string, purpose string, header *multipart.FileHeader) (models.Media, error) {
+	file, err := header.Open()
+	if err != nil {
+		return models.Media{}, err
+	}
+	defer file.Close()
+
+	return Upload(ctx, ownerId, purpose, file)
+}
+
+// Upload checks the file type and size, strips metadata, stores the file
+// under its content hash and records a media document. Images are left
+// PROCESSING until Process has generated their variants.
+func Upload(ctx context.Context, ownerId string, purpose string, r io.Reader) (models.Media, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize(purpose)+1))
+	if err != nil {
+		return models.Media{}, err
+	}
+
+	contentType, fileType, err := Sniff(data, purpose)
+	if err != nil {
+		return models.Media{}, err
+	}
+
+	data, err = StripMetadata(contentType, data)
+	if err != nil {
+		return models.Media{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	media := models.Media{
+		MediaID:     bson.NewObjectID().Hex(),
+		OwnerID:     ownerId,
+		Purpose:     purpose,
+		Kind:        fileType.Kind,
+		ContentType: contentType,
+		Hash:        hash,
+		Key:         objectKey(hash, "", fileType.Ext),
+		Size:        int64(len(data)),
+		Variants:    []models.MediaVariant{},
+		Status:      models.MEDIA_READY,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if fileType.Kind == KindImage {
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return models.Media{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if err := CheckPixels(config.Width, config.Height); err != nil {
+			return models.Media{}, err
+		}
+		media.Width = config.Width
+		media.Height = config.Height
+		media.Status = models.MEDIA_PROCESSING
+	}
+
+	// The same file was stored before, so only the document is new
+	var existing models.Media
+	err = database.MediaCollection.FindOne(ctx, bson.M{"hash": hash, "purpose": purpose, "status": models.MEDIA_READY, "direct": bson.M{"$ne": true}}).Decode(&existing)
+	switch {
+	case err == nil:
+		media.Variants = existing.Variants
+		media.Status = models.MEDIA_READY
+	case errors.Is(err, mongo.ErrNoDocuments):
+		if err := storage.Default().Put(ctx, media.Key, bytes.NewReader(data), contentType); err != nil {
+			return models.Media{}, err
+		}
+	default:
+		return models.Media{}, err
+	}
+
+	if _, err := database.MediaCollection.InsertOne(ctx, media); err != nil {
+		return models.Media{}, err
+	}
+
+	Sign(&media)
+	return media, nil
+}
+
+// Process generates the resized variants for an image. It is safe to run
+// more than once. Images that cannot be decoded are marked FAILED rather
+// than retried.
+func Process(ctx context.Context, mediaId string) error {
+	var media models.Media
+	if err := database.MediaCollection.FindOne(ctx, bson.M{"media_id": mediaId}).Decode(&media); err != nil {
+		return err
+	}
+
+	if media.Kind != KindImage || media.Status == models.MEDIA_READY {
+		return nil
+	}
+
+	data, err := storage.ReadAll(ctx, storage.Default(), media.Key)
+	if err != nil {
+		return err
+	}
+
+	if media.Direct {
+		if data, err = stripDirect(ctx, media, data); err != nil {
+			if errors.Is(err, ErrCorrupt) {
+				return markFailed(ctx, mediaId, err)
+			}
+			return err
+		}
+	}
+
+	// Direct uploads never went through Upload's dimension check
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return markFailed(ctx, mediaId, err)
+	}
+	if err := CheckPixels(config.Width, config.Height); err != nil {
+		return markFailed(ctx, mediaId, err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return markFailed(ctx, mediaId, err)
+	}
+
+	// Variant boxes are square, so rotating the small copies gives the same
+	// result as rotating the original first
+	orientation := Orientation(media.ContentType, data)
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	variants := []models.MediaVariant{}
+	for _, spec := range Variants[media.Purpose] {
+		encoded, contentType, err := Encode(Orient(Resize(img, spec), orientation))
+		if err != nil {
+			return markFailed(ctx, mediaId, err)
+		}
+
+		ext := ".jpg"
+		if contentType == "image/png" {
+			ext = ".png"
+		}
+
+		variant := models.MediaVariant{
+			Name:        spec.Name,
+			Key:         objectKey(media.Hash, spec.Name, ext),
+			ContentType: contentType,
+			Size:        int64(len(encoded)),
+		}
+		if decoded, _, err := image.DecodeConfig(bytes.NewReader(encoded)); err == nil {
+			variant.Width = decoded.Width
+			variant.Height = decoded.Height
+		}
+
+		if err := storage.Default().Put(ctx, variant.Key, bytes.NewReader(encoded), contentType); err != nil {
+			return err
+		}
+
+		variants = append(variants, variant)
+	}
+
+	update := bson.M{"$set": bson.M{
+		"variants":   variants,
+		"size":       int64(len(data)),
+		"width":      width,
+		"height":     height,
+		"status":     models.MEDIA_READY,
+		"updated_at": time.Now(),
+	}}
+	if _, err := database.MediaCollection.UpdateOne(ctx, bson.M{"media_id": mediaId}, update); err != nil {
+		return err
+	}
+
+	// Avatars switch to the resized copy once it exists
+	if media.Purpose == PurposeAvatar {
+		for _, variant := range variants {
+			if variant.Name == "avatar_256" {
+				_, err := database.UserCollection.UpdateOne(
+					ctx,
+					bson.M{"avatar_media_id": mediaId},
+					bson.M{"$set": bson.M{"avatar_key": variant.Key, "updated_at": time.Now()}},
+				)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func markFailed(ctx context.Context, mediaId string, cause error) error {
+	update := bson.M{"$set": bson.M{
+		"status":     models.MEDIA_FAILED,
+		"error":      cause.Error(),
+		"updated_at": time.Now(),
+	}}
+	_, err := database.MediaCollection.UpdateOne(ctx, bson.M{"media_id": mediaId}, update)
+	return err
+}
+
+// Attach links uploads to a post. Every id must belong to ownerId, be
+// confirmed and not be attached to another post yet.
+func Attach(ctx context.Context, postId string, ownerId string, mediaIds []string) error {
+	mediaIds = slices.Compact(slices.Sorted(slices.Values(mediaIds)))
+	if len(mediaIds) == 0 {
+		return nil
+	}
+
+	filter := bson.M{
+		"media_id": bson.M{"$in": mediaIds},
+		"owner_id": ownerId,
+		"purpose":  PurposePost,
+		"status":   bson.M{"$ne": models.MEDIA_PENDING},
+		"post_id":  bson.M{"$in": []any{nil, ""}},
+	}
+	update := bson.M{"$set": bson.M{"post_id": postId, "updated_at": time.Now()}}
+
+	result, err := database.MediaCollection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount != int64(len(mediaIds)) {
+		return ErrNotAttachable
+	}
+
+	return nil
+}
+
+// Release detaches the media of a deleted post so CleanupUnattached
+// removes it. Media still shown by a crosspost or its original is kept.
+func Release(ctx context.Context, postId string, mediaIds []string) error {
+	if len(mediaIds) == 0 {
+		return nil
+	}
+
+	var shared []string
+	err := database.PostCollection.Distinct(ctx, "media_ids", bson.M{
+		"media_ids": bson.M{"$in": mediaIds},
+		"post_id":   bson.M{"$ne": postId},
+	}).Decode(&shared)
+	if err != nil {
+		return err
+	}
+
+	var unused []string
+	for _, mediaId := range mediaIds {
+		if !slices.Contains(shared, mediaId) {
+			unused = append(unused, mediaId)
+		}
+	}
+	if len(unused) == 0 {
+		return nil
+	}
+
+	_, err = database.MediaCollection.UpdateMany(
+		ctx,
+		bson.M{"media_id": bson.M{"$in": unused}, "purpose": PurposePost},
+		bson.M{"$set": bson.M{"post_id": "", "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// FindByIDs loads media documents in the order of ids.
+func FindByIDs(ctx context.Context, ids []string) ([]models.Media, error) {
+	found := []models.Media{}
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	cursor, err := database.MediaCollection.Find(ctx, bson.M{"media_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var media []models.Media
+	if err := cursor.All(ctx, &media); err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]models.Media, len(media))
+	for _, m := range media {
+		byId[m.MediaID] = m
+	}
+	for _, id := range ids {
+		if m, ok := byId[id]; ok {
+			Sign(&m)
+			found = append(found, m)
+		}
+	}
+
+	return found, nil
+}
+
+// Sign fills in the short-lived URLs for a media document and its variants.
+// They are never stored because they expire.
+func Sign(media *models.Media) {
+	media.URL = storage.URL(media.Key)
+	for i := range media.Variants {
+		media.Variants[i].URL = storage.URL(media.Variants[i].Key)
+	}
+}
+
+// AvatarKey is the key shown for an avatar: the resized copy once it
+// exists, the original until then.
+func AvatarKey(media models.Media) string {
+	for _, variant := range media.Variants {
+		if variant.Name == "avatar_256" {
+			return variant.Key
+		}
+	}
+	return media.Key
+}
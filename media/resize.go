@@ -0,0 +1,113 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// VariantSpec describes a resized copy produced for an upload.
+type VariantSpec struct {
+	Name   string
+	Width  int
+	Height int
+	// Crop fills the whole box by cropping the centre instead of fitting
+	// the image inside it.
+	Crop bool
+}
+
+// Variants lists the copies generated for each purpose.
+var Variants = map[string][]VariantSpec{
+	PurposePost: {
+		{Name: "thumbnail", Width: 320, Height: 320},
+		{Name: "preview", Width: 1080, Height: 1080},
+	},
+	PurposeAvatar: {
+		{Name: "avatar_64", Width: 64, Height: 64, Crop: true},
+		{Name: "avatar_128", Width: 128, Height: 128, Crop: true},
+		{Name: "avatar_256", Width: 256, Height: 256, Crop: true},
+	},
+}
+
+// Resize scales img into the spec's box. Images are never scaled up.
+func Resize(img image.Image, spec VariantSpec) image.Image {
+	source := img.Bounds()
+
+	if spec.Crop {
+		side := min(source.Dx(), source.Dy())
+		x := source.Min.X + (source.Dx()-side)/2
+		y := source.Min.Y + (source.Dy()-side)/2
+		source = image.Rect(x, y, x+side, y+side)
+	}
+
+	width, height := source.Dx(), source.Dy()
+	scale := min(float64(spec.Width)/float64(width), float64(spec.Height)/float64(height), 1)
+	width = max(1, int(float64(width)*scale))
+	height = max(1, int(float64(height)*scale))
+
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), img, source, draw.Src, nil)
+	return resized
+}
+
+// Orient applies an EXIF orientation (1-8) so the image is stored upright.
+// Other values leave img as it is.
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	oriented := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = bounds.Dx()-1-x, y
+			case 3: // rotated 180
+				dx, dy = bounds.Dx()-1-x, bounds.Dy()-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, bounds.Dy()-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = bounds.Dy()-1-y, x
+			case 7: // transversed
+				dx, dy = bounds.Dy()-1-y, bounds.Dx()-1-x
+			case 8: // rotated 90 counter-clockwise
+				dx, dy = y, bounds.Dx()-1-x
+			}
+			oriented.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return oriented
+}
+
+// Encode writes img as JPEG when it is fully opaque and as PNG otherwise,
+// so transparency survives.
+func Encode(img image.Image) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
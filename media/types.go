@@ -0,0 +1,111 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	KindImage = "image"
+	KindVideo = "video"
+
+	PurposePost   = "post"
+	PurposeAvatar = "avatar"
+
+	// sniffLength is how much of a file http.DetectContentType looks at.
+	sniffLength = 512
+)
+
+// Type is an allowed upload type and how large it may be.
+type Type struct {
+	Kind    string
+	Ext     string
+	MaxSize int64
+}
+
+// AllowedTypes maps sniffed content types to the upload rules for them.
+// Anything not listed is rejected whatever its file extension says.
+var AllowedTypes = map[string]Type{
+	"image/jpeg": {Kind: KindImage, Ext: ".jpg", MaxSize: 20 << 20},
+	"image/png":  {Kind: KindImage, Ext: ".png", MaxSize: 20 << 20},
+	"image/gif":  {Kind: KindImage, Ext: ".gif", MaxSize: 20 << 20},
+	"image/webp": {Kind: KindImage, Ext: ".webp", MaxSize: 20 << 20},
+	"video/mp4":  {Kind: KindVideo, Ext: ".mp4", MaxSize: 200 << 20},
+	"video/webm": {Kind: KindVideo, Ext: ".webm", MaxSize: 200 << 20},
+}
+
+// MaxAvatarSize is the size limit for avatars, which must be images.
+const MaxAvatarSize = 5 << 20
+
+// MaxPixels caps the dimensions of images, since a small file can declare
+// a huge canvas that takes gigabytes of memory to decode.
+const MaxPixels = 40_000_000
+
+var (
+	ErrUnsupportedType = errors.New("unsupported file type")
+	ErrTooLarge        = errors.New("file is too large")
+	ErrEmpty           = errors.New("file is empty")
+)
+
+// Sniff detects the content type from the file's first bytes and checks it
+// against the allowlist and the size limits for purpose.
+func Sniff(data []byte, purpose string) (string, Type, error) {
+	if len(data) == 0 {
+		return "", Type{}, ErrEmpty
+	}
+
+	contentType := http.DetectContentType(data[:min(len(data), sniffLength)])
+
+	fileType, err := Check(contentType, int64(len(data)), purpose)
+	return contentType, fileType, err
+}
+
+// CheckPixels rejects images whose declared dimensions exceed MaxPixels.
+func CheckPixels(width int, height int) error {
+	if width <= 0 || height <= 0 || int64(width)*int64(height) > MaxPixels {
+		return fmt.Errorf("%w: %dx%d pixels", ErrTooLarge, width, height)
+	}
+	return nil
+}
+
+// Check validates a content type and size for purpose, for files whose
+// bytes are not at hand yet.
+func Check(contentType string, size int64, purpose string) (Type, error) {
+	if size <= 0 {
+		return Type{}, ErrEmpty
+	}
+
+	fileType, ok := AllowedTypes[contentType]
+	if !ok {
+		return Type{}, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	maxSize := fileType.MaxSize
+	if purpose == PurposeAvatar {
+		if fileType.Kind != KindImage {
+			return Type{}, fmt.Errorf("%w: avatars must be images", ErrUnsupportedType)
+		}
+		maxSize = MaxAvatarSize
+	}
+
+	if size > maxSize {
+		return Type{}, fmt.Errorf("%w: limit is %d MB", ErrTooLarge, maxSize>>20)
+	}
+
+	return fileType, nil
+}
+
+// MaxSize is the largest file accepted for purpose, used to bound reads
+// before the type is known.
+func MaxSize(purpose string) int64 {
+	if purpose == PurposeAvatar {
+		return MaxAvatarSize
+	}
+
+	var largest int64
+	for _, fileType := range AllowedTypes {
+		largest = max(largest, fileType.MaxSize)
+	}
+	return largest
+}
@@ -37,3 +37,37 @@ func AuthMiddleware() gin.HandlerFunc {
 
 	}
 }
+
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != "ADMIN" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+	}
+}
+
+// OptionalAuthMiddleware identifies the user when a valid token is sent but
+// lets anonymous requests through, for public routes that personalise
+// their results.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			return
+		}
+
+		token, err := utils.GetAuthToken(c)
+		if err != nil || token == "" {
+			return
+		}
+
+		claims, err := utils.VerifyAuthToken(token)
+		if err != nil {
+			return
+		}
+
+		c.Set("userId", claims.UserId)
+		c.Set("role", claims.Role)
+	}
+}
@@ -0,0 +1,210 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Migration is one versioned change to the database. Pending migrations run
+// in ascending version order and each is recorded once it succeeds. Up must
+// be safe to run again, since two replicas starting together can both see
+// the same migration as pending.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context) error
+}
+
+// Status is a migration and when it was applied, if it was.
+type Status struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+}
+
+// Applied returns the versions recorded in the migrations collection.
+func Applied(ctx context.Context) (map[int]models.MigrationRecord, error) {
+	cursor, err := database.MigrationCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []models.MigrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]models.MigrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// Pending returns the migrations that have not been applied, in order.
+func Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range sorted() {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// List reports every known migration and whether it has been applied.
+func List(ctx context.Context) ([]Status, error) {
+	applied, err := Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, migration := range sorted() {
+		status := Status{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Run applies every pending migration and returns the versions it applied.
+// It stops at the first failure so later migrations never run on top of a
+// missing one.
+func Run(ctx context.Context) ([]int, error) {
+	_, err := database.MigrationCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("indexing migrations: %w", err)
+	}
+
+	pending, err := Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+	for _, migration := range pending {
+		start := time.Now()
+		if err := migration.Up(ctx); err != nil {
+			return versions, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		record := models.MigrationRecord{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+			DurationMs:  time.Since(start).Milliseconds(),
+		}
+		_, err := database.MigrationCollection.UpdateOne(
+			ctx,
+			bson.M{"version": migration.Version},
+			bson.M{"$setOnInsert": record},
+			options.UpdateOne().SetUpsert(true),
+		)
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return versions, fmt.Errorf("recording migration %d: %w", migration.Version, err)
+		}
+
+		versions = append(versions, migration.Version)
+	}
+
+	return versions, nil
+}
+
+func sorted() []Migration {
+	return slices.SortedFunc(slices.Values(All), func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+}
+
+// indexes returns a migration step that creates the given indexes on a
+// collection. Creating an index that already exists with the same options
+// is a no-op.
+func indexes(collection *mongo.Collection, specs ...mongo.IndexModel) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if _, err := collection.Indexes().CreateMany(ctx, specs); err != nil {
+			return fmt.Errorf("indexing %s: %w", collection.Name(), err)
+		}
+		return nil
+	}
+}
+
+// steps runs several migration steps in order.
+func steps(fns ...func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for _, fn := range fns {
+			if err := fn(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func unique(keys ...string) mongo.IndexModel {
+	return mongo.IndexModel{Keys: ascending(keys...), Options: options.Index().SetUnique(true)}
+}
+
+func index(keys bson.D) mongo.IndexModel {
+	return mongo.IndexModel{Keys: keys}
+}
+
+// expiring removes documents once the date in key is older than after.
+// Documents without the field are kept.
+func expiring(key string, after time.Duration) mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys:    bson.D{{Key: key, Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(after.Seconds())),
+	}
+}
+
+func ascending(keys ...string) bson.D {
+	doc := bson.D{}
+	for _, key := range keys {
+		doc = append(doc, bson.E{Key: key, Value: 1})
+	}
+	return doc
+}
+
+// uniqueUsernames replaces the plain username index with a unique one that
+// ignores case, like mentions do, and skips users without a username.
+func uniqueUsernames(ctx context.Context) error {
+	err := database.UserCollection.Indexes().DropOne(ctx, "username_1")
+	if err != nil && !isIndexNotFound(err) {
+		return fmt.Errorf("dropping username index: %w", err)
+	}
+
+	return indexes(database.UserCollection, mongo.IndexModel{
+		Keys: bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().
+			SetName("username_unique").
+			SetUnique(true).
+			SetCollation(&options.Collation{Locale: "en", Strength: 2}).
+			SetPartialFilterExpression(bson.M{"username": bson.M{"$type": "string", "$gt": ""}}),
+	})(ctx)
+}
+
+func isIndexNotFound(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && (serverErr.HasErrorCode(27) || serverErr.HasErrorCode(26))
+}
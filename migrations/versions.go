@@ -0,0 +1,137 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/services"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// All is every migration. Append new ones with the next version; never
+// change or renumber one that has shipped. A unique index fails to build
+// while duplicates exist, so the migration stays pending until they are
+// cleaned up.
+var All = []Migration{
+	{
+		Version:     1,
+		Description: "unique user ids and emails",
+		Up: indexes(database.UserCollection,
+			unique("user_id"),
+			unique("email"),
+			index(bson.D{{Key: "username", Value: 1}}),
+		),
+	},
+	{
+		Version:     2,
+		Description: "one vote per user on each post, comment and poll",
+		Up: steps(
+			indexes(database.PostVoteCollection,
+				unique("user_id", "post_id"),
+				index(bson.D{{Key: "post_id", Value: 1}}),
+				index(bson.D{{Key: "author_id", Value: 1}, {Key: "subreddit_id", Value: 1}}),
+			),
+			indexes(database.CommentVoteCollection,
+				unique("user_id", "comment_id"),
+				index(bson.D{{Key: "comment_id", Value: 1}}),
+				index(bson.D{{Key: "author_id", Value: 1}, {Key: "subreddit_id", Value: 1}}),
+			),
+			indexes(database.PollVoteCollection, unique("user_id", "post_id")),
+		),
+	},
+	{
+		Version:     3,
+		Description: "one membership per user in each subreddit",
+		Up: indexes(database.MemberCollection,
+			unique("user_id", "subreddit_id"),
+			index(bson.D{{Key: "subreddit_id", Value: 1}, {Key: "role", Value: 1}}),
+		),
+	},
+	{
+		Version:     4,
+		Description: "unique follows, blocks, bans, karma, saved and hidden items",
+		Up: steps(
+			indexes(database.FollowCollection,
+				unique("follower_id", "followee_id"),
+				index(bson.D{{Key: "followee_id", Value: 1}, {Key: "created_at", Value: -1}}),
+			),
+			indexes(database.BlockCollection, unique("user_id", "blocked_user_id"), index(bson.D{{Key: "blocked_user_id", Value: 1}})),
+			indexes(database.SubredditBanCollection, unique("subreddit_id", "user_id")),
+			indexes(database.SubredditKarmaCollection, unique("user_id", "subreddit_id")),
+			indexes(database.SavedCollection, unique("user_id", "collection", "post_id", "comment_id")),
+			indexes(database.HiddenPostCollection, unique("user_id", "post_id")),
+		),
+	},
+	{
+		Version:     5,
+		Description: "unique public ids",
+		Up: steps(
+			indexes(database.PostCollection, unique("post_id")),
+			indexes(database.CommentCollection, unique("comment_id")),
+			indexes(database.SubredditCollection, unique("subreddit_id")),
+			indexes(database.MediaCollection, unique("media_id")),
+			indexes(database.DraftCollection, unique("post_id")),
+			indexes(database.NotificationCollection, unique("notification_id")),
+			indexes(database.ConversationCollection, unique("conversation_id")),
+			indexes(database.MessageCollection, unique("message_id")),
+			indexes(database.ModLogCollection, unique("log_id")),
+			indexes(database.OutboxCollection, unique("outbox_id")),
+			indexes(database.ScheduledJobCollection, unique("name")),
+		),
+	},
+	{
+		Version:     6,
+		Description: "indexes for listings and background jobs",
+		Up: steps(
+			indexes(database.PostCollection,
+				index(bson.D{{Key: "created_at", Value: -1}}),
+				index(bson.D{{Key: "subreddit_id", Value: 1}, {Key: "created_at", Value: -1}}),
+				index(bson.D{{Key: "author_url", Value: 1}, {Key: "created_at", Value: -1}}),
+				index(bson.D{{Key: "domain", Value: 1}, {Key: "created_at", Value: -1}}),
+				index(bson.D{{Key: "tags", Value: 1}}),
+				index(bson.D{{Key: "crosspost_parent_id", Value: 1}}),
+			),
+			indexes(database.CommentCollection,
+				index(bson.D{{Key: "post_id", Value: 1}, {Key: "created_at", Value: -1}}),
+				index(bson.D{{Key: "parent_id", Value: 1}}),
+				index(bson.D{{Key: "author_url", Value: 1}, {Key: "created_at", Value: -1}}),
+			),
+			indexes(database.DraftCollection,
+				index(bson.D{{Key: "author_url", Value: 1}, {Key: "updated_at", Value: -1}}),
+				index(bson.D{{Key: "status", Value: 1}, {Key: "scheduled_at", Value: 1}}),
+			),
+			indexes(database.NotificationCollection, index(bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}, {Key: "created_at", Value: -1}})),
+			indexes(database.ConversationCollection, index(bson.D{{Key: "participant_ids", Value: 1}, {Key: "last_message_at", Value: -1}})),
+			indexes(database.MessageCollection, index(bson.D{{Key: "conversation_id", Value: 1}, {Key: "created_at", Value: -1}})),
+			indexes(database.ModLogCollection, index(bson.D{{Key: "subreddit_id", Value: 1}, {Key: "created_at", Value: -1}})),
+			indexes(database.MediaCollection, index(bson.D{{Key: "purpose", Value: 1}, {Key: "post_id", Value: 1}, {Key: "created_at", Value: 1}})),
+			indexes(database.OutboxCollection, index(bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}})),
+		),
+	},
+	{
+		Version:     7,
+		Description: "recount subreddit members",
+		Up:          services.RecountMembers,
+	},
+	{
+		Version:     8,
+		Description: "unique usernames",
+		Up:          uniqueUsernames,
+	},
+	{
+		Version:     9,
+		Description: "copy legacy post votes",
+		Up:          copyLegacyVotes,
+	},
+	{
+		Version:     10,
+		Description: "count stickied posts",
+		Up:          services.RecountStickied,
+	},
+	{
+		// Published entries are kept as long as the job idempotency keys
+		Version:     11,
+		Description: "expire published outbox entries",
+		Up:          indexes(database.OutboxCollection, expiring("published_at", 7*24*time.Hour)),
+	},
+}
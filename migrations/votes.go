@@ -0,0 +1,172 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/services"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const voteBatchSize = 500
+
+// copyLegacyVotes moves the votes cast through the old post_upvote and
+// post_downvote collections into post_votes, then recounts the posts they
+// touched and everyone's karma. A vote already in post_votes wins, and
+// votes on deleted posts are dropped. The old collections are left as they
+// were.
+func copyLegacyVotes(ctx context.Context) error {
+	posts := make(map[string]*models.Post)
+
+	if err := copyVotes(ctx, database.PostUpVoteCollection, models.UPVOTE, posts); err != nil {
+		return fmt.Errorf("copying upvotes: %w", err)
+	}
+	if err := copyVotes(ctx, database.PostDownVoteCollection, models.DOWNVOTE, posts); err != nil {
+		return fmt.Errorf("copying downvotes: %w", err)
+	}
+
+	var postIds []string
+	for postId, post := range posts {
+		if post != nil {
+			postIds = append(postIds, postId)
+		}
+	}
+	if err := recountVotes(ctx, postIds); err != nil {
+		return fmt.Errorf("recounting votes: %w", err)
+	}
+
+	return services.ReconcileKarma(ctx)
+}
+
+// copyVotes upserts every vote in a legacy collection. posts caches the
+// author and subreddit of each post seen, or nil when it no longer exists.
+func copyVotes(ctx context.Context, collection *mongo.Collection, value int, posts map[string]*models.Post) error {
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	findOptions := options.FindOne().SetProjection(bson.M{"post_id": 1, "author_url": 1, "subreddit_id": 1})
+
+	var writes []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var vote models.PostUpvote
+		if err := cursor.Decode(&vote); err != nil {
+			return err
+		}
+		if vote.UserID == "" || vote.PostID == "" {
+			continue
+		}
+
+		post, seen := posts[vote.PostID]
+		if !seen {
+			var found models.Post
+			err := database.PostCollection.FindOne(ctx, bson.M{"post_id": vote.PostID}, findOptions).Decode(&found)
+			switch {
+			case err == nil:
+				post = &found
+			case !errors.Is(err, mongo.ErrNoDocuments):
+				return err
+			}
+			posts[vote.PostID] = post
+		}
+		if post == nil {
+			continue
+		}
+
+		now := time.Now()
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"user_id": vote.UserID, "post_id": vote.PostID}).
+			SetUpdate(bson.M{"$setOnInsert": bson.M{
+				"value":        value,
+				"author_id":    post.AuthorID,
+				"subreddit_id": post.SubredditID,
+				"created_at":   now,
+				"updated_at":   now,
+			}}).
+			SetUpsert(true))
+
+		if len(writes) == voteBatchSize {
+			if err := writeVotes(ctx, writes); err != nil {
+				return err
+			}
+			writes = nil
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return writeVotes(ctx, writes)
+}
+
+// writeVotes ignores duplicate keys, which only mean a vote was cast at the
+// same time through the new endpoint.
+func writeVotes(ctx context.Context, writes []mongo.WriteModel) error {
+	if len(writes) == 0 {
+		return nil
+	}
+	_, err := database.PostVoteCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	return nil
+}
+
+// recountVotes sets the vote counters and score of posts from post_votes.
+// down_vote is kept as a negative count, as VotePost does.
+func recountVotes(ctx context.Context, postIds []string) error {
+	for start := 0; start < len(postIds); start += voteBatchSize {
+		batch := postIds[start:min(start+voteBatchSize, len(postIds))]
+
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"post_id": bson.M{"$in": batch}}}},
+			{{Key: "$group", Value: bson.M{
+				"_id":       "$post_id",
+				"up_vote":   bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$value", models.UPVOTE}}, 1, 0}}},
+				"down_vote": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$value", models.DOWNVOTE}}, -1, 0}}},
+				"score":     bson.M{"$sum": "$value"},
+			}}},
+		}
+
+		cursor, err := database.PostVoteCollection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+
+		var counts []struct {
+			PostID   string `bson:"_id"`
+			UpVote   int    `bson:"up_vote"`
+			DownVote int    `bson:"down_vote"`
+			Score    int    `bson:"score"`
+		}
+		if err := cursor.All(ctx, &counts); err != nil {
+			return err
+		}
+
+		var writes []mongo.WriteModel
+		for _, count := range counts {
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"post_id": count.PostID}).
+				SetUpdate(bson.M{"$set": bson.M{
+					"up_vote":   count.UpVote,
+					"down_vote": count.DownVote,
+					"score":     count.Score,
+				}}))
+		}
+		if len(writes) == 0 {
+			continue
+		}
+		if _, err := database.PostCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Drafts and scheduled posts are kept out of the posts collection until they
+// are published, so no listing or counter ever sees them.
+const (
+	POST_DRAFT     = "DRAFT"
+	POST_SCHEDULED = "SCHEDULED"
+)
+
+// DraftDTO replaces the editable parts of a draft. A nil ScheduledAt turns a
+// scheduled post back into a plain draft.
+type DraftDTO struct {
+	Title       string     `json:"title" validate:"required"`
+	Content     string     `json:"content"`
+	Tags        []string   `json:"tags"`
+	NSFW        bool       `json:"nsfw"`
+	Spoiler     bool       `json:"spoiler"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type ContentSettingsDTO struct {
+	ShowNSFW bool `json:"show_nsfw"`
+}
+
+type FeedPage struct {
+	Posts       []Post    `json:"posts"`
+	Page        int       `json:"page"`
+	HasMore     bool      `json:"has_more"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Follow struct {
+	ID         bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	FollowerID string        `json:"follower_id" bson:"follower_id"`
+	FolloweeID string        `json:"followee_id" bson:"followee_id"`
+	CreatedAt  time.Time     `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const LINK_POST = "link"
+
+const (
+	PREVIEW_PENDING = "PENDING"
+	PREVIEW_READY   = "READY"
+	PREVIEW_FAILED  = "FAILED"
+)
+
+// LinkPreview is the card shown for a link post, filled in by a background
+// job from the page's OpenGraph and Twitter card tags.
+type LinkPreview struct {
+	Title       string     `json:"title,omitempty" bson:"title,omitempty"`
+	Description string     `json:"description,omitempty" bson:"description,omitempty"`
+	ImageURL    string     `json:"image_url,omitempty" bson:"image_url,omitempty"`
+	SiteName    string     `json:"site_name,omitempty" bson:"site_name,omitempty"`
+	Status      string     `json:"status" bson:"status"`
+	Error       string     `json:"error,omitempty" bson:"error,omitempty"`
+	FetchedAt   *time.Time `json:"fetched_at,omitempty" bson:"fetched_at,omitempty"`
+}
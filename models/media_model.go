@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	MEDIA_PENDING    = "PENDING"
+	MEDIA_PROCESSING = "PROCESSING"
+	MEDIA_READY      = "READY"
+	MEDIA_FAILED     = "FAILED"
+)
+
+// MediaVariant is a resized copy of an image, such as a thumbnail.
+type MediaVariant struct {
+	Name        string `json:"name" bson:"name"`
+	Key         string `json:"key" bson:"key"`
+	URL         string `json:"url" bson:"-"`
+	ContentType string `json:"content_type" bson:"content_type"`
+	Width       int    `json:"width" bson:"width"`
+	Height      int    `json:"height" bson:"height"`
+	Size        int64  `json:"size" bson:"size"`
+}
+
+// Media is an uploaded file. Key is derived from the content hash, so the
+// same file uploaded twice is stored once, except for Direct uploads which
+// the client sent straight to storage. URL is signed on read.
+type Media struct {
+	ID          bson.ObjectID  `json:"_id" bson:"_id,omitempty"`
+	MediaID     string         `json:"media_id" bson:"media_id"`
+	OwnerID     string         `json:"owner_id" bson:"owner_id"`
+	Purpose     string         `json:"purpose" bson:"purpose"`
+	Kind        string         `json:"kind" bson:"kind"`
+	ContentType string         `json:"content_type" bson:"content_type"`
+	Hash        string         `json:"hash" bson:"hash"`
+	Key         string         `json:"key" bson:"key"`
+	URL         string         `json:"url" bson:"-"`
+	Size        int64          `json:"size" bson:"size"`
+	Width       int            `json:"width" bson:"width"`
+	Height      int            `json:"height" bson:"height"`
+	Variants    []MediaVariant `json:"variants" bson:"variants"`
+	Status      string         `json:"status" bson:"status"`
+	Error       string         `json:"error,omitempty" bson:"error,omitempty"`
+	PostID      string         `json:"post_id,omitempty" bson:"post_id,omitempty"`
+	Direct      bool           `json:"direct" bson:"direct"`
+	UploadID    string         `json:"-" bson:"upload_id,omitempty"`
+	CreatedAt   time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" bson:"updated_at"`
+}
+
+// UploadSlotDTO describes a file the client wants to upload straight to
+// storage.
+type UploadSlotDTO struct {
+	ContentType string `json:"content_type" validate:"required"`
+	Size        int64  `json:"size" validate:"required"`
+	Multipart   bool   `json:"multipart"`
+}
+
+type UploadPartURL struct {
+	PartNumber int    `json:"part_number"`
+	URL        string `json:"url"`
+}
+
+// UploadSlot tells the client where to send the file. Single uploads use
+// URL with Headers, multipart uploads send each PartSize chunk to its part
+// URL and keep the returned ETags for confirmation.
+type UploadSlot struct {
+	Media     Media             `json:"media"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	PartSize  int64             `json:"part_size,omitempty"`
+	Parts     []UploadPartURL   `json:"parts,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+type SignUploadPartsDTO struct {
+	PartNumbers []int `json:"part_numbers" validate:"required"`
+}
+
+type UploadedPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+// ConfirmUploadDTO finishes a direct upload. SHA256 is optional and checked
+// against the stored bytes when given.
+type ConfirmUploadDTO struct {
+	SHA256 string         `json:"sha256"`
+	Parts  []UploadedPart `json:"parts"`
+}
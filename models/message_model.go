@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	ConversationDirect  = "DIRECT"
+	ConversationGroup   = "GROUP"
+	ConversationModmail = "MODMAIL"
+)
+
+type Conversation struct {
+	ID             bson.ObjectID        `json:"_id" bson:"_id,omitempty"`
+	ConversationID string               `json:"conversation_id" bson:"conversation_id"`
+	Type           string               `json:"type" bson:"type" validate:"oneof DIRECT GROUP MODMAIL"`
+	Title          string               `json:"title" bson:"title"`
+	ParticipantIDs []string             `json:"participant_ids" bson:"participant_ids"`
+	SubredditID    string               `json:"subreddit_id" bson:"subreddit_id"`
+	CreatorID      string               `json:"creator_id" bson:"creator_id"`
+	ReadReceipts   map[string]time.Time `json:"read_receipts" bson:"read_receipts"`
+	LastMessageAt  time.Time            `json:"last_message_at" bson:"last_message_at"`
+	CreatedAt      time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+type Message struct {
+	ID             bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	MessageID      string        `json:"message_id" bson:"message_id"`
+	ConversationID string        `json:"conversation_id" bson:"conversation_id"`
+	SenderID       string        `json:"sender_id" bson:"sender_id"`
+	Body           string        `json:"body" bson:"body" validate:"required"`
+	CreatedAt      time.Time     `json:"created_at" bson:"created_at"`
+}
+
+type Block struct {
+	ID            bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	UserID        string        `json:"user_id" bson:"user_id"`
+	BlockedUserID string        `json:"blocked_user_id" bson:"blocked_user_id" validate:"required"`
+	CreatedAt     time.Time     `json:"created_at" bson:"created_at"`
+}
+
+type CreateConversationDTO struct {
+	ParticipantIDs []string `json:"participant_ids" validate:"required"`
+	Title          string   `json:"title"`
+	Body           string   `json:"body"`
+}
+
+type CreateModmailDTO struct {
+	SubredditID string `json:"subreddit_id" validate:"required"`
+	Title       string `json:"title" validate:"required"`
+	Body        string `json:"body" validate:"required"`
+}
+
+type SendMessageDTO struct {
+	Body string `json:"body" validate:"required"`
+}
+
+type CursorPage[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+}
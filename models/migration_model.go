@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MigrationRecord marks a migration version as applied.
+type MigrationRecord struct {
+	ID          bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	Version     int           `json:"version" bson:"version"`
+	Description string        `json:"description" bson:"description"`
+	AppliedAt   time.Time     `json:"applied_at" bson:"applied_at"`
+	DurationMs  int64         `json:"duration_ms" bson:"duration_ms"`
+}
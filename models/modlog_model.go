@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SYSTEM_MODERATOR is the moderator recorded for automatic actions such as
+// archiving.
+const SYSTEM_MODERATOR = "system"
+
+const (
+	MOD_LOCK           = "lock"
+	MOD_UNLOCK         = "unlock"
+	MOD_STICKY         = "sticky"
+	MOD_UNSTICKY       = "unsticky"
+	MOD_MARK_NSFW      = "mark_nsfw"
+	MOD_UNMARK_NSFW    = "unmark_nsfw"
+	MOD_MARK_SPOILER   = "mark_spoiler"
+	MOD_UNMARK_SPOILER = "unmark_spoiler"
+	MOD_ARCHIVE        = "archive"
+	MOD_BAN            = "ban"
+	MOD_UNBAN          = "unban"
+)
+
+// ModLogEntry records a moderation action in a subreddit.
+type ModLogEntry struct {
+	ID          bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	LogID       string        `json:"log_id" bson:"log_id"`
+	SubredditID string        `json:"subreddit_id" bson:"subreddit_id"`
+	ModeratorID string        `json:"moderator_id" bson:"moderator_id"`
+	Action      string        `json:"action" bson:"action"`
+	TargetType  string        `json:"target_type" bson:"target_type"`
+	TargetID    string        `json:"target_id" bson:"target_id"`
+	Details     string        `json:"details,omitempty" bson:"details,omitempty"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+}
+
+// PostModerationDTO changes the flags that are set; nil fields are left
+// alone.
+type PostModerationDTO struct {
+	Locked   *bool `json:"locked"`
+	Stickied *bool `json:"stickied"`
+	NSFW     *bool `json:"nsfw"`
+	Spoiler  *bool `json:"spoiler"`
+}
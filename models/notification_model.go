@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	NotificationPostReply      = "POST_REPLY"
+	NotificationCommentReply   = "COMMENT_REPLY"
+	NotificationMention        = "MENTION"
+	NotificationModerator      = "MODERATOR"
+	NotificationScoreMilestone = "SCORE_MILESTONE"
+)
+
+var NotificationTypes = []string{
+	NotificationPostReply,
+	NotificationCommentReply,
+	NotificationMention,
+	NotificationModerator,
+	NotificationScoreMilestone,
+}
+
+type Notification struct {
+	ID             bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	NotificationID string        `json:"notification_id" bson:"notification_id"`
+	UserID         string        `json:"user_id" bson:"user_id"`
+	ActorID        string        `json:"actor_id" bson:"actor_id"`
+	Type           string        `json:"type" bson:"type"`
+	PostID         string        `json:"post_id" bson:"post_id"`
+	CommentID      string        `json:"comment_id" bson:"comment_id"`
+	SubredditID    string        `json:"subreddit_id" bson:"subreddit_id"`
+	Message        string        `json:"message" bson:"message"`
+	Read           bool          `json:"read" bson:"read"`
+	Emailed        bool          `json:"emailed" bson:"emailed"`
+	InApp          bool          `json:"-" bson:"in_app"`
+	CreatedAt      time.Time     `json:"created_at" bson:"created_at"`
+}
+
+type NotificationPreference struct {
+	InApp bool `json:"in_app" bson:"in_app"`
+	Email bool `json:"email" bson:"email"`
+}
+
+type NotificationPreferencesDTO struct {
+	Preferences map[string]NotificationPreference `json:"preferences" validate:"required"`
+}
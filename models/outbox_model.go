@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type OutboxEntry struct {
+	ID             bson.ObjectID          `json:"_id" bson:"_id,omitempty"`
+	OutboxID       string                 `json:"outbox_id" bson:"outbox_id"`
+	IdempotencyKey string                 `json:"idempotency_key" bson:"idempotency_key"`
+	Namespace      string                 `json:"namespace" bson:"namespace"`
+	JobName        string                 `json:"job_name" bson:"job_name"`
+	Args           map[string]interface{} `json:"args" bson:"args"`
+	Status         string                 `json:"status" bson:"status" validate:"oneof PENDING PUBLISHING PUBLISHED"`
+	LockedUntil    time.Time              `json:"locked_until" bson:"locked_until,omitempty"`
+	Attempts       int                    `json:"attempts" bson:"attempts"`
+	LastError      string                 `json:"last_error" bson:"last_error"`
+	CreatedAt      time.Time              `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at" bson:"updated_at"`
+	PublishedAt    time.Time              `json:"published_at" bson:"published_at,omitempty"`
+}
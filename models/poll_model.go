@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const POLL_POST = "poll"
+
+const (
+	MIN_POLL_OPTIONS = 2
+	MAX_POLL_OPTIONS = 6
+)
+
+type PollOption struct {
+	OptionID string `json:"option_id" bson:"option_id"`
+	Text     string `json:"text" bson:"text"`
+	Votes    int    `json:"votes" bson:"votes"`
+}
+
+// Poll is stored on its post. The counts are never sent as is; clients get
+// a PollSummary that hides them until the user has voted or the poll closed.
+type Poll struct {
+	Options    []PollOption `json:"options" bson:"options"`
+	TotalVotes int          `json:"total_votes" bson:"total_votes"`
+	ClosesAt   time.Time    `json:"closes_at" bson:"closes_at"`
+}
+
+// PollVote is a user's single vote in a poll.
+type PollVote struct {
+	ID        bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	PostID    string        `json:"post_id" bson:"post_id"`
+	UserID    string        `json:"user_id" bson:"user_id"`
+	OptionID  string        `json:"option_id" bson:"option_id"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+}
+
+type PollVoteDTO struct {
+	OptionID string `json:"option_id" validate:"required"`
+}
+
+// PollOptionResult leaves Votes out while results are hidden.
+type PollOptionResult struct {
+	OptionID string `json:"option_id"`
+	Text     string `json:"text"`
+	Votes    *int   `json:"votes,omitempty"`
+}
+
+// PollSummary is the compact poll returned with posts.
+type PollSummary struct {
+	Options        []PollOptionResult `json:"options"`
+	TotalVotes     int                `json:"total_votes"`
+	ClosesAt       time.Time          `json:"closes_at"`
+	Closed         bool               `json:"closed"`
+	VotedOptionID  string             `json:"voted_option_id,omitempty"`
+	ResultsVisible bool               `json:"results_visible"`
+}
@@ -12,7 +12,16 @@ type Post struct {
 	Title        string        `json:"title" form:"title" bson:"title" validate:"required"`
 	Content      string        `json:"content" form:"content" bson:"content" validate:"required"`
 	Type         string        `json:"type" form:"type" bson:"type"`
+	URL          string        `json:"url,omitempty" form:"url" bson:"url,omitempty"`
+	Domain       string        `json:"domain,omitempty" bson:"domain,omitempty"`
+	LinkPreview  *LinkPreview  `json:"link_preview,omitempty" bson:"link_preview,omitempty"`
+	Poll         *Poll         `json:"-" bson:"poll,omitempty"`
+	PollSummary  *PollSummary  `json:"poll,omitempty" bson:"-"`
+	PollOptions  []string      `json:"poll_options,omitempty" form:"poll_options" bson:"-"`
+	PollClosesAt time.Time     `json:"poll_closes_at,omitzero" form:"poll_closes_at" time_format:"2006-01-02T15:04:05Z07:00" bson:"-"`
 	FileUrls     []string      `json:"file_urls" bson:"file_urls"`
+	MediaIDs     []string      `json:"media_ids" form:"media_ids" bson:"media_ids"`
+	Media        []Media       `json:"media,omitempty" bson:"-"`
 	AuthorID     string        `json:"author_url" form:"author_url" bson:"author_url"`
 	SubredditID  string        `json:"subreddit_id" form:"subreddit_id" bson:"subreddit_id"`
 	Score        int           `json:"score" bson:"score"`
@@ -21,11 +30,35 @@ type Post struct {
 	UpVote       int           `json:"up_vote" bson:"up_vote"`
 	DownVote     int           `json:"down_vote" bson:"down_vote"`
 	Embeddings   []float32     `json:"embeddings" bson:"embeddings"`
+	NSFW         bool          `json:"nsfw" form:"nsfw" bson:"nsfw"`
+	Spoiler      bool          `json:"spoiler" form:"spoiler" bson:"spoiler"`
+	Locked       bool          `json:"locked" bson:"locked"`
+	Stickied     bool          `json:"stickied" bson:"stickied"`
+	Archived     bool          `json:"archived" bson:"archived"`
+	Status       string        `json:"status,omitempty" form:"status" bson:"status,omitempty"`
+	ScheduledAt  *time.Time    `json:"scheduled_at,omitempty" form:"scheduled_at" time_format:"2006-01-02T15:04:05Z07:00" bson:"scheduled_at,omitempty"`
+	PublishError string        `json:"publish_error,omitempty" bson:"publish_error,omitempty"`
+
+	CrosspostParentID          string   `json:"crosspost_parent_id,omitempty" bson:"crosspost_parent_id,omitempty"`
+	CrosspostParentSubredditID string   `json:"crosspost_parent_subreddit_id,omitempty" bson:"crosspost_parent_subreddit_id,omitempty"`
+	CrosspostParentAuthorID    string   `json:"crosspost_parent_author_id,omitempty" bson:"crosspost_parent_author_id,omitempty"`
+	CrosspostCount             int      `json:"crosspost_count" bson:"crosspost_count"`
+	CrosspostedTo              []string `json:"crossposted_to,omitempty" bson:"crossposted_to,omitempty"`
+
+	// ScoreMilestones are the milestones the author was already notified of
+	ScoreMilestones []int `json:"-" bson:"score_milestones,omitempty"`
 
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
 }
 
+// CrosspostDTO shares a post into another subreddit. Title defaults to the
+// original's.
+type CrosspostDTO struct {
+	SubredditID string `json:"subreddit_id" validate:"required"`
+	Title       string `json:"title"`
+}
+
 type TagsPosts struct {
 	Posts     []Post `json:"posts" bson:"posts"`
 	PostCount int    `json:"post_count" bson:"post_count"`
@@ -41,3 +74,34 @@ subreddit_id    UUID (FK → subreddits.id)
 score           INT DEFAULT 0
 created_at      TIMESTAMP
 updated_at      TIMESTAMP*/
+
+type PostDraft struct {
+	Title       string `json:"title" validate:"required"`
+	Content     string `json:"content"`
+	SubredditID string `json:"subreddit_id"`
+}
+
+type TagSuggestion struct {
+	Tag   string  `json:"tag"`
+	Score float32 `json:"score"`
+}
+
+type SubredditSuggestion struct {
+	SubredditID string  `json:"subreddit_id"`
+	Name        string  `json:"name"`
+	Score       float32 `json:"score"`
+}
+
+type DuplicatePost struct {
+	PostID      string  `json:"post_id"`
+	Title       string  `json:"title"`
+	SubredditID string  `json:"subreddit_id"`
+	Similarity  float32 `json:"similarity"`
+}
+
+type PostSuggestion struct {
+	Tags       []TagSuggestion       `json:"tags"`
+	Subreddits []SubredditSuggestion `json:"subreddits"`
+	Duplicates []DuplicatePost       `json:"duplicates"`
+	Hints      []string              `json:"hints"`
+}
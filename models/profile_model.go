@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PublicProfileDTO is what anyone can see about a user.
+type PublicProfileDTO struct {
+	UserId         string    `json:"user_id"`
+	Username       string    `json:"username"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	Avatar         string    `json:"avatar"`
+	PostKarma      int       `json:"post_karma"`
+	CommentKarma   int       `json:"comment_karma"`
+	TotalKarma     int       `json:"total_karma"`
+	FollowersCount int       `json:"followers_count"`
+	FollowingCount int       `json:"following_count"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// OverviewItem is a post or comment in a user's combined history.
+type OverviewItem struct {
+	Kind         string    `json:"kind" bson:"kind"`
+	PostID       string    `json:"post_id" bson:"post_id"`
+	CommentID    string    `json:"comment_id,omitempty" bson:"comment_id,omitempty"`
+	Title        string    `json:"title,omitempty" bson:"title,omitempty"`
+	Content      string    `json:"content" bson:"content"`
+	SubredditID  string    `json:"subreddit_id,omitempty" bson:"subreddit_id,omitempty"`
+	Score        int       `json:"score" bson:"score"`
+	CommentCount int       `json:"comment_count" bson:"comment_count"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+}
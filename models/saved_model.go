@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SavedItem is a post or comment a user saved. CommentID is empty for posts.
+type SavedItem struct {
+	ID         bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	SavedID    string        `json:"saved_id" bson:"saved_id"`
+	UserID     string        `json:"user_id" bson:"user_id"`
+	PostID     string        `json:"post_id" bson:"post_id"`
+	CommentID  string        `json:"comment_id" bson:"comment_id"`
+	Collection string        `json:"collection" bson:"collection"`
+	CreatedAt  time.Time     `json:"created_at" bson:"created_at"`
+}
+
+type HiddenPost struct {
+	ID        bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	UserID    string        `json:"user_id" bson:"user_id"`
+	PostID    string        `json:"post_id" bson:"post_id"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+}
+
+type SavedItemDTO struct {
+	SavedItem `bson:",inline"`
+	Post      *Post    `json:"post,omitempty"`
+	Comment   *Comment `json:"comment,omitempty"`
+}
+
+type SaveItemDTO struct {
+	PostID     string `json:"post_id"`
+	CommentID  string `json:"comment_id"`
+	Collection string `json:"collection"`
+}
+
+type SavedCollectionDTO struct {
+	Name  string `json:"name" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+}
+
+type RenameCollectionDTO struct {
+	Name string `json:"name" validate:"required"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type ScheduledJob struct {
+	ID         bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	Name       string        `json:"name" bson:"name"`
+	Spec       string        `json:"spec" bson:"spec"`
+	LastRunAt  time.Time     `json:"last_run_at" bson:"last_run_at"`
+	DurationMs int64         `json:"duration_ms" bson:"duration_ms"`
+	Outcome    string        `json:"outcome" bson:"outcome" validate:"oneof SUCCESS FAILED"`
+	LastError  string        `json:"last_error" bson:"last_error"`
+	Instance   string        `json:"instance" bson:"instance"`
+	RunCount   int           `json:"run_count" bson:"run_count"`
+	UpdatedAt  time.Time     `json:"updated_at" bson:"updated_at"`
+}
@@ -7,15 +7,43 @@ import (
 )
 
 type SubReddit struct {
-	ID           bson.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	SubRedditId  string        `json:"subreddit_id" bson:"subreddit_id"`
-	Name         string        `json:"name" bson:"name" validate:"required,min=2,max=50"`
-	Description  string        `json:"description" bson:"description" validate:"required,min=2,max=200"`
-	CreatorId    string        `json:"creator_id" bson:"creator_id" validate:"required"`
-	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at" bson:"updated_at"`
-	MembersCount int           `json:"members_count" bson:"members_count"`
-	PostsCount   int           `json:"posts_count" bson:"posts_count"`
+	ID           bson.ObjectID  `json:"_id,omitempty" bson:"_id,omitempty"`
+	SubRedditId  string         `json:"subreddit_id" bson:"subreddit_id"`
+	Name         string         `json:"name" bson:"name" validate:"required,min=2,max=50"`
+	Description  string         `json:"description" bson:"description" validate:"required,min=2,max=200"`
+	CreatorId    string         `json:"creator_id" bson:"creator_id" validate:"required"`
+	CreatedAt    time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at" bson:"updated_at"`
+	MembersCount int            `json:"members_count" bson:"members_count"`
+	PostsCount   int            `json:"posts_count" bson:"posts_count"`
+	Rules        SubredditRules `json:"rules" bson:"rules"`
+
+	// StickiedCount is how many of the subreddit's posts are stickied. It
+	// only changes together with a post's stickied flag.
+	StickiedCount int `json:"-" bson:"stickied_count"`
+}
+
+type SubredditRules struct {
+	MinKarmaToPost     int  `json:"min_karma_to_post" bson:"min_karma_to_post" validate:"min=0"`
+	CrosspostsDisabled bool `json:"crossposts_disabled" bson:"crossposts_disabled"`
+}
+
+// SubredditBan stops a user from posting in a subreddit, until ExpiresAt
+// when it is set.
+type SubredditBan struct {
+	ID          bson.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	SubredditID string        `json:"subreddit_id" bson:"subreddit_id"`
+	UserID      string        `json:"user_id" bson:"user_id"`
+	BannedBy    string        `json:"banned_by" bson:"banned_by"`
+	Reason      string        `json:"reason" bson:"reason"`
+	ExpiresAt   *time.Time    `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+}
+
+// BanDTO bans for Days days, or permanently when Days is 0.
+type BanDTO struct {
+	Reason string `json:"reason"`
+	Days   int    `json:"days" validate:"min=0"`
 }
 
 type SubRedditMembers struct {
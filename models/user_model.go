@@ -9,6 +9,7 @@ import (
 type User struct {
 	ID               bson.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
 	UserId           string        `json:"user_id" bson:"user_id"`
+	Username         string        `json:"username" bson:"username"`
 	Email            string        `json:"email" bson:"email" validate:"required,email"`
 	Password         string        `json:"password" bson:"password" validate:"required,min=6"`
 	FirstName        string        `json:"first_name" bson:"first_name" validate:"required,min=2,max=100"`
@@ -19,10 +20,20 @@ type User struct {
 	VerficationToken string        `json:"verification_token" bson:"verification_token"`
 	EmailVerified    bool          `json:"email_verified" bson:"email_verified"`
 	Avatar           string        `json:"avatar" bson:"avatar"`
-	Karma            string        `json:"karma" bson:"karma"`
+	AvatarMediaID    string        `json:"-" bson:"avatar_media_id"`
+	AvatarKey        string        `json:"-" bson:"avatar_key"`
+	Locale           string        `json:"locale" bson:"locale"`
+	ShowNSFW         bool          `json:"show_nsfw" bson:"show_nsfw"`
+	PostKarma        int           `json:"-" bson:"post_karma"`
+	CommentKarma     int           `json:"-" bson:"comment_karma"`
+	FollowersCount   int           `json:"-" bson:"followers_count"`
+	FollowingCount   int           `json:"-" bson:"following_count"`
 	ResetToken       string        `json:"reset_token" bson:"reset_token"`
-	CreatedAt        time.Time     `json:"created_at" bson:"created_at"`
-	UpdatedAt        time.Time     `json:"updated_at" bson:"updated_at"`
+
+	NotificationPreferences map[string]NotificationPreference `json:"notification_preferences" bson:"notification_preferences"`
+
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
 }
 
 type UserLogin struct {
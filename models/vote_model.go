@@ -1,6 +1,16 @@
 package models
 
-import "go.mongodb.org/mongo-driver/v2/bson"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	UPVOTE   = 1
+	NO_VOTE  = 0
+	DOWNVOTE = -1
+)
 
 type PostUpvote struct {
 	ID     bson.ObjectID `json:"_id" bson:"_id,omitempty"`
@@ -16,18 +26,57 @@ type PostDownvote struct {
 	Value  int           `json:"value" bson:"value"`
 }
 
-type CommentUpvote struct {
-	ID        bson.ObjectID `json:"_id" bson:"_id,omitempty"`
-	CommentID string        `json:"comment_id" bson:"comment_id"`
-	UserID    string        `json:"user_id" bson:"user_id"`
-	Value     int           `json:"value" bson:"value"`
+// PostVote is a user's single vote on a post. AuthorID and SubredditID are
+// copied from the post so karma can be rebuilt from votes alone.
+type PostVote struct {
+	ID          bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	PostID      string        `json:"post_id" bson:"post_id"`
+	UserID      string        `json:"user_id" bson:"user_id"`
+	AuthorID    string        `json:"author_id" bson:"author_id"`
+	SubredditID string        `json:"subreddit_id" bson:"subreddit_id"`
+	Value       int           `json:"value" bson:"value" validate:"oneof=1 -1"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+type CommentVote struct {
+	ID          bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	CommentID   string        `json:"comment_id" bson:"comment_id"`
+	PostID      string        `json:"post_id" bson:"post_id"`
+	UserID      string        `json:"user_id" bson:"user_id"`
+	AuthorID    string        `json:"author_id" bson:"author_id"`
+	SubredditID string        `json:"subreddit_id" bson:"subreddit_id"`
+	Value       int           `json:"value" bson:"value" validate:"oneof=1 -1"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+type VoteDTO struct {
+	Value int `json:"value" validate:"oneof=1 0 -1"`
+}
+
+type VoteResult struct {
+	Previous int  `json:"previous"`
+	Value    int  `json:"value"`
+	Score    int  `json:"score"`
+	Changed  bool `json:"changed"`
+}
+
+type SubredditKarma struct {
+	ID           bson.ObjectID `json:"_id" bson:"_id,omitempty"`
+	UserID       string        `json:"user_id" bson:"user_id"`
+	SubredditID  string        `json:"subreddit_id" bson:"subreddit_id"`
+	PostKarma    int           `json:"post_karma" bson:"post_karma"`
+	CommentKarma int           `json:"comment_karma" bson:"comment_karma"`
+	UpdatedAt    time.Time     `json:"updated_at" bson:"updated_at"`
 }
 
-type CommentDownvote struct {
-	ID        bson.ObjectID `json:"_id" bson:"_id,omitempty"`
-	CommentID string        `json:"comment_id" bson:"comment_id"`
-	UserID    string        `json:"user_id" bson:"user_id"`
-	Value     int           `json:"value" bson:"value"`
+type KarmaDTO struct {
+	UserID       string           `json:"user_id"`
+	PostKarma    int              `json:"post_karma"`
+	CommentKarma int              `json:"comment_karma"`
+	TotalKarma   int              `json:"total_karma"`
+	Subreddits   []SubredditKarma `json:"subreddits"`
 }
 
 /*post_votes
@@ -0,0 +1,163 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/config"
+)
+
+const channelPrefix = "realtime:"
+
+const (
+	CommentCreated      = "comment.created"
+	CommentRemoved      = "comment.removed"
+	PostRemoved         = "post.removed"
+	PostUpdated         = "post.updated"
+	PostVoteChanged     = "post.vote"
+	CommentVoteChanged  = "comment.vote"
+	NotificationCreated = "notification.created"
+	MessageCreated      = "message.created"
+	ConversationRead    = "conversation.read"
+)
+
+type Event struct {
+	Type      string    `json:"type"`
+	Channel   string    `json:"channel"`
+	Data      any       `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func PostChannel(postId string) string {
+	return "post:" + postId
+}
+
+func SubredditChannel(subredditId string) string {
+	return "subreddit:" + subredditId
+}
+
+func UserChannel(userId string) string {
+	return "user:" + userId
+}
+
+// Publish sends an event through Redis so subscribers on every replica
+// receive it.
+func Publish(ctx context.Context, channel string, eventType string, data any) error {
+	event := Event{
+		Type:      eventType,
+		Channel:   channel,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return config.Redis.Publish(ctx, channelPrefix+channel, payload).Err()
+}
+
+// PublishAll publishes the same event on several channels, logging failures
+// since realtime delivery is best effort.
+func PublishAll(ctx context.Context, eventType string, data any, channels ...string) {
+	for _, channel := range channels {
+		if err := Publish(ctx, channel, eventType, data); err != nil {
+			fmt.Println("Error publishing realtime event:", err.Error())
+		}
+	}
+}
+
+// Subscription receives events for the channels it was created with.
+type Subscription struct {
+	C        chan Event
+	channels []string
+	hub      *Hub
+}
+
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+// Hub keeps a single Redis pattern subscription per process and fans events
+// out to local subscribers.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscription]bool
+	once        sync.Once
+}
+
+var DefaultHub = &Hub{subscribers: make(map[string]map[*Subscription]bool)}
+
+func Subscribe(channels ...string) *Subscription {
+	return DefaultHub.Subscribe(channels...)
+}
+
+func (h *Hub) Subscribe(channels ...string) *Subscription {
+	h.once.Do(func() { go h.listen() })
+
+	subscription := &Subscription{
+		C:        make(chan Event, 32),
+		channels: channels,
+		hub:      h,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, channel := range channels {
+		if h.subscribers[channel] == nil {
+			h.subscribers[channel] = make(map[*Subscription]bool)
+		}
+		h.subscribers[channel][subscription] = true
+	}
+
+	return subscription
+}
+
+func (h *Hub) unsubscribe(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, channel := range subscription.channels {
+		delete(h.subscribers[channel], subscription)
+		if len(h.subscribers[channel]) == 0 {
+			delete(h.subscribers, channel)
+		}
+	}
+}
+
+func (h *Hub) listen() {
+	for {
+		pubsub := config.Redis.PSubscribe(context.Background(), channelPrefix+"*")
+
+		for message := range pubsub.Channel() {
+			var event Event
+			if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+				continue
+			}
+			h.dispatch(strings.TrimPrefix(message.Channel, channelPrefix), event)
+		}
+
+		pubsub.Close()
+		time.Sleep(time.Second)
+	}
+}
+
+// dispatch drops events for subscribers that are not keeping up rather than
+// blocking every other connection.
+func (h *Hub) dispatch(channel string, event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for subscription := range h.subscribers[channel] {
+		select {
+		case subscription.C <- event:
+		default:
+		}
+	}
+}
@@ -1,11 +1,95 @@
 package routes
 
 import (
+	"github.com/EsanSamuel/Reddit_Clone/controllers"
 	"github.com/EsanSamuel/Reddit_Clone/middlewares"
+	"github.com/EsanSamuel/Reddit_Clone/models"
 	"github.com/gin-gonic/gin"
 )
 
 func ProtectedRoutes(r *gin.Engine) {
-	r.Use(middlewares.AuthMiddleware())
+	protected := r.Group("/")
+	protected.Use(middlewares.AuthMiddleware())
 
+	protected.GET("/notifications", controllers.GetNotifications())
+	protected.GET("/notifications/unread-count", controllers.GetUnreadNotificationCount())
+	protected.PATCH("/notifications/read-all", controllers.MarkAllNotificationsRead())
+	protected.PATCH("/notifications/:id/read", controllers.MarkNotificationRead())
+	protected.GET("/notifications/preferences", controllers.GetNotificationPreferences())
+	protected.PUT("/notifications/preferences", controllers.UpdateNotificationPreferences())
+
+	protected.GET("/feed/home", controllers.GetHomeFeed())
+	protected.GET("/feed/recommended", controllers.GetRecommendedFeed())
+	protected.PUT("/settings/content", controllers.UpdateContentSettings())
+	protected.PATCH("/avatar", controllers.UploadAvatar())
+
+	protected.POST("/users/:userId/follow", controllers.FollowUser())
+	protected.DELETE("/users/:userId/follow", controllers.UnfollowUser())
+	protected.GET("/users/:userId/upvoted", controllers.GetVotedPosts(models.UPVOTE))
+	protected.GET("/users/:userId/downvoted", controllers.GetVotedPosts(models.DOWNVOTE))
+	protected.GET("/users/:userId/saved", controllers.GetSavedItems())
+	protected.GET("/users/:userId/hidden", controllers.GetHiddenPosts())
+
+	protected.POST("/saved", controllers.SaveItem())
+	protected.DELETE("/saved/:id", controllers.UnsaveItem())
+	protected.GET("/saved/collections", controllers.GetSavedCollections())
+	protected.PATCH("/saved/collections/:name", controllers.RenameSavedCollection())
+	protected.DELETE("/saved/collections/:name", controllers.DeleteSavedCollection())
+	protected.POST("/posts/:id/hide", controllers.HidePost())
+	protected.DELETE("/posts/:id/hide", controllers.UnhidePost())
+
+	protected.POST("/media", controllers.UploadMedia())
+	protected.POST("/media/uploads", controllers.CreateUploadSlot())
+	protected.POST("/media/uploads/:id/parts", controllers.SignUploadParts())
+	protected.POST("/media/uploads/:id/confirm", controllers.ConfirmUpload())
+	protected.DELETE("/media/uploads/:id", controllers.AbortUpload())
+
+	protected.POST("/posts", controllers.CreatePost())
+	protected.POST("/posts/suggest", controllers.SuggestPostDetails())
+	protected.DELETE("/posts/:id", controllers.DeletePost())
+	protected.POST("/posts/:id/vote", controllers.VotePost())
+	protected.POST("/post/upvote", controllers.UpVotePost())
+	protected.POST("/post/downvote", controllers.DownVotePost())
+	protected.POST("/posts/:id/poll/vote", controllers.VotePoll())
+	protected.POST("/posts/:id/crosspost", controllers.CrosspostPost())
+	protected.PATCH("/posts/:id/moderation", controllers.ModeratePost())
+	protected.GET("/posts/drafts", controllers.GetDrafts())
+	protected.PUT("/posts/:id/draft", controllers.UpdateDraft())
+	protected.DELETE("/posts/:id/draft", controllers.DeleteDraft())
+	protected.POST("/posts/:id/publish", controllers.PublishDraft())
+	protected.POST("/comments", controllers.CreateComment())
+	protected.DELETE("/comments/:id", controllers.DeleteComment())
+	protected.POST("/comments/:id/vote", controllers.VoteComment())
+	protected.POST("/subreddit/member", controllers.JoinSubreddit())
+	protected.POST("/subreddit/moderator", controllers.AddModerators())
+	protected.PATCH("/subreddits/:id/rules", controllers.UpdateSubredditRules())
+	protected.DELETE("/subreddits/:id/members", controllers.LeaveSubreddit())
+	protected.GET("/subreddits/:id/bans", controllers.GetSubredditBans())
+	protected.GET("/subreddits/:id/modlog", controllers.GetModLog())
+	protected.POST("/subreddits/:id/bans/:userId", controllers.BanUser())
+	protected.DELETE("/subreddits/:id/bans/:userId", controllers.UnbanUser())
+
+	protected.POST("/messages/conversations", controllers.CreateConversation())
+	protected.POST("/messages/modmail", controllers.CreateModmail())
+	protected.GET("/messages/conversations", controllers.GetConversations())
+	protected.GET("/messages/conversations/:id", controllers.GetConversationMessages())
+	protected.POST("/messages/conversations/:id", controllers.SendMessage())
+	protected.PATCH("/messages/conversations/:id/read", controllers.MarkConversationRead())
+	protected.GET("/blocks", controllers.GetBlockedUsers())
+	protected.POST("/blocks", controllers.BlockUser())
+	protected.DELETE("/blocks/:user_id", controllers.UnblockUser())
+
+	protected.GET("/realtime/sse", controllers.StreamEvents())
+	protected.GET("/realtime/ws", controllers.WebSocketEvents())
+
+	admin := protected.Group("/admin")
+	admin.Use(middlewares.AdminMiddleware())
+	admin.GET("/jobs", controllers.GetJobQueues())
+	admin.GET("/jobs/:namespace/pending/:job_name", controllers.GetPendingJobs())
+	admin.GET("/jobs/:namespace/retry", controllers.GetRetryJobs())
+	admin.DELETE("/jobs/:namespace/retry/:retry_at/:job_id", controllers.DeleteRetryJob())
+	admin.GET("/jobs/:namespace/dead", controllers.GetDeadJobs())
+	admin.POST("/jobs/:namespace/dead/:died_at/:job_id/retry", controllers.RetryDeadJob())
+	admin.DELETE("/jobs/:namespace/dead/:died_at/:job_id", controllers.DeleteDeadJob())
+	admin.GET("/scheduler", controllers.GetScheduledJobs())
 }
@@ -2,6 +2,8 @@ package routes
 
 import (
 	"github.com/EsanSamuel/Reddit_Clone/controllers"
+	"github.com/EsanSamuel/Reddit_Clone/middlewares"
+	"github.com/EsanSamuel/Reddit_Clone/storage"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,24 +16,36 @@ func UnProtectedRoutes(r *gin.Engine) {
 	r.PATCH("/reset-password-request", controllers.ResetPasswordRequest())
 	r.GET("/users", controllers.GetAllUsers())
 	r.GET("/users/:userId", controllers.GetUser())
-	r.PATCH("/avatar/:userId", controllers.UploadAvatar())
+	r.GET("/users/:userId/karma", controllers.GetUserKarma())
+	r.GET("/users/:userId/posts", controllers.GetUserPosts())
+	r.GET("/users/:userId/comments", controllers.GetUserComments())
+	r.GET("/users/:userId/overview", controllers.GetUserOverview())
+	r.GET("/users/:userId/followers", controllers.GetFollowers())
+	r.GET("/users/:userId/following", controllers.GetFollowing())
 	r.POST("/subreddit", controllers.CreateSubreddit())
-	r.POST("/subreddit/member", controllers.JoinSubreddit())
-	r.POST("subreddit/moderator", controllers.AddModerators())
 	r.GET("/subreddits", controllers.GetSubReddit())
 	r.GET("/subreddits/user/:user_id", controllers.GetSubRedditUserJoined())
 	r.GET("/subreddits/:id", controllers.GetSubRedditById())
-	r.POST("/posts", controllers.CreatePost())
-	r.GET("/posts", controllers.GetPosts())
-	r.GET("/posts/subreddit/:subreddit_id", controllers.GetSubRedditPosts())
+	r.GET("/posts", middlewares.OptionalAuthMiddleware(), controllers.GetPosts())
+	r.GET("/posts/subreddit/:subreddit_id", middlewares.OptionalAuthMiddleware(), controllers.GetSubRedditPosts())
 	r.GET("/tags/posts", controllers.GetTagPosts())
+	r.GET("/domains/:domain/posts", controllers.GetDomainPosts())
 	r.GET("/posts/:id", controllers.GetPostById())
-	r.POST("/comments", controllers.CreateComment())
+	r.GET("/posts/:id/media", controllers.GetPostMedia())
+	r.GET("/posts/:id/poll", middlewares.OptionalAuthMiddleware(), controllers.GetPoll())
+	r.GET("/posts/:id/crossposts", controllers.GetCrossposts())
+	r.GET("/media/:id", controllers.GetMedia())
+
+	// Files kept on the local disk are only served behind a signed URL
+	if local, ok := storage.Default().(*storage.LocalStore); ok {
+		files := r.Group("/files", local.VerifySignature())
+		files.Static("/", local.Dir())
+		files.PUT("/*filepath", local.ReceiveUpload())
+	}
+
 	r.GET("/comments/post/:post_id", controllers.GetPostComments())
 	r.GET("/comments/parent/:parent_id)", controllers.GetParentComments())
 	r.GET("/comments/:id", controllers.GetCommentById())
-	r.POST("/post/upvote", controllers.UpVotePost())
-	r.POST("/post/downvote", controllers.DownVotePost())
 	r.GET("/summary/:post_id", controllers.ThreadsSummary())
 	r.POST("/rag/:postId", controllers.SeachPostDetailsWithAI())
 	//r.POST("/upload", controllers.UploadFiles())
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// IsBlocked reports whether either user has blocked the other.
+func IsBlocked(ctx context.Context, userId string, otherUserId string) (bool, error) {
+	count, err := database.BlockCollection.CountDocuments(ctx, bson.M{
+		"$or": []bson.M{
+			{"user_id": userId, "blocked_user_id": otherUserId},
+			{"user_id": otherUserId, "blocked_user_id": userId},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// BlockedUserIDs lists users hidden from userId: the ones they blocked and
+// the ones who blocked them.
+func BlockedUserIDs(ctx context.Context, userId string) ([]string, error) {
+	cursor, err := database.BlockCollection.Find(ctx, bson.M{
+		"$or": []bson.M{
+			{"user_id": userId},
+			{"blocked_user_id": userId},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var blocks []models.Block
+	if err := cursor.All(ctx, &blocks); err != nil {
+		return nil, err
+	}
+
+	userIds := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.UserID == userId {
+			userIds = append(userIds, block.BlockedUserID)
+		} else {
+			userIds = append(userIds, block.UserID)
+		}
+	}
+
+	return userIds, nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	ErrCrosspostSameSubreddit = errors.New("post is already in this subreddit")
+	ErrAlreadyCrossposted     = errors.New("post was already crossposted to this subreddit")
+)
+
+// Crosspost shares a post into another subreddit as a new post that points
+// back at the original. Crossposts of crossposts point at the first
+// original, which counts every crosspost and lists where it was shared.
+// outbox queues the new post's background jobs in the same transaction.
+func Crosspost(ctx context.Context, userId string, postId string, dto models.CrosspostDTO, outbox func(ctx context.Context, postId string) error) (models.Post, error) {
+	var original models.Post
+	if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": postId}).Decode(&original); err != nil {
+		return models.Post{}, err
+	}
+
+	if original.CrosspostParentID != "" {
+		if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": original.CrosspostParentID}).Decode(&original); err != nil {
+			return models.Post{}, err
+		}
+	}
+
+	if dto.SubredditID == original.SubredditID {
+		return models.Post{}, ErrCrosspostSameSubreddit
+	}
+	if slices.Contains(original.CrosspostedTo, dto.SubredditID) {
+		return models.Post{}, ErrAlreadyCrossposted
+	}
+
+	if err := CheckCrosspostRules(ctx, userId, dto.SubredditID); err != nil {
+		return models.Post{}, err
+	}
+
+	title := strings.TrimSpace(dto.Title)
+	if title == "" {
+		title = original.Title
+	}
+
+	// Polls are voted on in the original, so the crosspost has no poll
+	crosspost := models.Post{
+		PostID:                     bson.NewObjectID().Hex(),
+		Title:                      title,
+		Content:                    original.Content,
+		Type:                       original.Type,
+		URL:                        original.URL,
+		Domain:                     original.Domain,
+		LinkPreview:                original.LinkPreview,
+		MediaIDs:                   original.MediaIDs,
+		AuthorID:                   userId,
+		SubredditID:                dto.SubredditID,
+		Tags:                       original.Tags,
+		NSFW:                       original.NSFW,
+		CrosspostParentID:          original.PostID,
+		CrosspostParentSubredditID: original.SubredditID,
+		CrosspostParentAuthorID:    original.AuthorID,
+		CreatedAt:                  time.Now(),
+		UpdatedAt:                  time.Now(),
+	}
+
+	err := database.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := database.PostCollection.InsertOne(ctx, crosspost); err != nil {
+			return err
+		}
+
+		_, err := database.SubredditCollection.UpdateOne(
+			ctx,
+			bson.M{"subreddit_id": dto.SubredditID},
+			bson.M{"$inc": bson.M{"posts_count": 1}},
+		)
+		if err != nil {
+			return err
+		}
+
+		// Each subreddit gets one crosspost, so crosspost_count always
+		// matches crossposted_to even when two requests race
+		result, err := database.PostCollection.UpdateOne(
+			ctx,
+			bson.M{"post_id": original.PostID, "crossposted_to": bson.M{"$ne": dto.SubredditID}},
+			bson.M{
+				"$inc":      bson.M{"crosspost_count": 1},
+				"$addToSet": bson.M{"crossposted_to": dto.SubredditID},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrAlreadyCrossposted
+		}
+
+		return outbox(ctx, crosspost.PostID)
+	})
+	if err != nil {
+		return models.Post{}, fmt.Errorf("crossposting %s: %w", original.PostID, err)
+	}
+
+	return crosspost, nil
+}
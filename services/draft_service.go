@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/media"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Posts can be scheduled from a minute to 30 days ahead.
+const (
+	MinScheduleAhead = time.Minute
+	MaxScheduleAhead = 30 * 24 * time.Hour
+)
+
+const publishBatchSize = 100
+
+var (
+	ErrInvalidPostStatus = errors.New("status must be draft or published")
+	ErrInvalidSchedule   = errors.New("scheduled_at must be between 1 minute and 30 days from now")
+)
+
+func checkSchedule(at time.Time) error {
+	ahead := time.Until(at)
+	if ahead < MinScheduleAhead || ahead > MaxScheduleAhead {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+// PrepareDraft normalizes the status of a new post. A post with a
+// scheduled_at is scheduled, one with status=draft is a draft and anything
+// else is published right away.
+func PrepareDraft(post *models.Post) error {
+	post.PublishError = ""
+
+	if post.ScheduledAt != nil {
+		if err := checkSchedule(*post.ScheduledAt); err != nil {
+			return err
+		}
+		post.Status = models.POST_SCHEDULED
+		return nil
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(post.Status)) {
+	case "", "PUBLISHED":
+		post.Status = ""
+	case models.POST_DRAFT:
+		post.Status = models.POST_DRAFT
+	default:
+		return ErrInvalidPostStatus
+	}
+	return nil
+}
+
+// IsDraft reports whether a prepared post should be saved as a draft
+// instead of published.
+func IsDraft(post models.Post) bool {
+	return post.Status == models.POST_DRAFT || post.Status == models.POST_SCHEDULED
+}
+
+// SaveDraft stores a draft or scheduled post and claims its media. Nothing
+// is counted or queued until the post is published.
+func SaveDraft(ctx context.Context, post models.Post) error {
+	return database.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := database.DraftCollection.InsertOne(ctx, post); err != nil {
+			return err
+		}
+
+		return media.Attach(ctx, post.PostID, post.AuthorID, post.MediaIDs)
+	})
+}
+
+// UpdateDraft replaces the editable parts of one of the user's drafts and
+// schedules or unschedules it.
+func UpdateDraft(ctx context.Context, userId string, postId string, dto models.DraftDTO) (models.Post, error) {
+	set := bson.M{
+		"title":      strings.TrimSpace(dto.Title),
+		"content":    dto.Content,
+		"tags":       dto.Tags,
+		"nsfw":       dto.NSFW,
+		"spoiler":    dto.Spoiler,
+		"status":     models.POST_DRAFT,
+		"updated_at": time.Now(),
+	}
+	unset := bson.M{"publish_error": ""}
+
+	if dto.ScheduledAt != nil {
+		if err := checkSchedule(*dto.ScheduledAt); err != nil {
+			return models.Post{}, err
+		}
+		set["status"] = models.POST_SCHEDULED
+		set["scheduled_at"] = *dto.ScheduledAt
+	} else {
+		unset["scheduled_at"] = ""
+	}
+
+	var draft models.Post
+	err := database.DraftCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"post_id": postId, "author_url": userId},
+		bson.M{"$set": set, "$unset": unset},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&draft)
+
+	return draft, err
+}
+
+// DeleteDraft discards one of the user's drafts.
+func DeleteDraft(ctx context.Context, userId string, postId string) error {
+	result, err := database.DraftCollection.DeleteOne(ctx, bson.M{"post_id": postId, "author_url": userId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// PublishDraft moves a draft into the posts collection. The subreddit
+// counter and outbox jobs are only written here, when the post goes live.
+// Posting rules are checked again since the author may have been banned
+// since drafting. Polls keep the duration they were drafted with.
+func PublishDraft(ctx context.Context, postId string, outbox func(ctx context.Context, post models.Post) error) (models.Post, error) {
+	var post models.Post
+	if err := database.DraftCollection.FindOne(ctx, bson.M{"post_id": postId}).Decode(&post); err != nil {
+		return models.Post{}, err
+	}
+
+	if err := CheckPostingRules(ctx, post.AuthorID, post.SubredditID); err != nil {
+		return models.Post{}, err
+	}
+
+	now := time.Now()
+	if post.Poll != nil {
+		post.Poll.ClosesAt = post.Poll.ClosesAt.Add(now.Sub(post.CreatedAt))
+	}
+
+	post.ID = bson.ObjectID{}
+	post.Status = ""
+	post.ScheduledAt = nil
+	post.PublishError = ""
+	post.CreatedAt = now
+	post.UpdatedAt = now
+
+	err := database.WithTransaction(ctx, func(ctx context.Context) error {
+		// Deleting first makes concurrent publishes of the same draft lose
+		result, err := database.DraftCollection.DeleteOne(ctx, bson.M{"post_id": postId})
+		if err != nil {
+			return err
+		}
+		if result.DeletedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+
+		if _, err := database.PostCollection.InsertOne(ctx, post); err != nil {
+			return err
+		}
+
+		if post.SubredditID != "" {
+			_, err := database.SubredditCollection.UpdateOne(
+				ctx,
+				bson.M{"subreddit_id": post.SubredditID},
+				bson.M{"$inc": bson.M{"posts_count": 1}},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return outbox(ctx, post)
+	})
+	if err != nil {
+		return models.Post{}, fmt.Errorf("publishing draft %s: %w", postId, err)
+	}
+
+	if err := NotifyMentions(ctx, post.AuthorID, post.Title+"\n"+post.Content, post.PostID, ""); err != nil {
+		fmt.Println("Error notifying mentions:", err.Error())
+	}
+
+	return post, nil
+}
+
+// PublishDuePosts publishes every scheduled post whose time has come.
+// Posts that can no longer be published go back to being drafts with the
+// reason recorded, so the author can fix and reschedule them. Posts that
+// fail for any other reason keep their schedule with the error recorded
+// and are retried on the next run, without holding up the others.
+func PublishDuePosts(ctx context.Context, outbox func(ctx context.Context, post models.Post) error) error {
+	failed := []string{}
+	findOptions := options.Find().
+		SetProjection(bson.M{"post_id": 1}).
+		SetSort(bson.D{{Key: "scheduled_at", Value: 1}}).
+		SetLimit(publishBatchSize)
+
+	for {
+		filter := bson.M{
+			"status":       models.POST_SCHEDULED,
+			"scheduled_at": bson.M{"$lte": time.Now()},
+			"post_id":      bson.M{"$nin": failed},
+		}
+		cursor, err := database.DraftCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			return err
+		}
+
+		var due []models.Post
+		if err := cursor.All(ctx, &due); err != nil {
+			return err
+		}
+
+		for _, draft := range due {
+			var reason string
+			_, err := PublishDraft(ctx, draft.PostID, outbox)
+			switch {
+			case err == nil:
+				continue
+			case errors.Is(err, ErrBanned), errors.Is(err, ErrInsufficientKarma):
+				reason = err.Error()
+			case errors.Is(err, mongo.ErrNoDocuments):
+				// Either the subreddit is gone or the draft was deleted meanwhile
+				reason = "subreddit not found"
+			default:
+				fmt.Println("Error publishing scheduled post:", err.Error())
+				failed = append(failed, draft.PostID)
+
+				_, updateErr := database.DraftCollection.UpdateOne(
+					ctx,
+					bson.M{"post_id": draft.PostID, "status": models.POST_SCHEDULED},
+					bson.M{"$set": bson.M{"publish_error": err.Error(), "updated_at": time.Now()}},
+				)
+				if updateErr != nil {
+					fmt.Println("Error recording publish error:", updateErr.Error())
+				}
+				continue
+			}
+
+			_, err = database.DraftCollection.UpdateOne(
+				ctx,
+				bson.M{"post_id": draft.PostID, "status": models.POST_SCHEDULED},
+				bson.M{
+					"$set":   bson.M{"status": models.POST_DRAFT, "publish_error": reason, "updated_at": time.Now()},
+					"$unset": bson.M{"scheduled_at": ""},
+				},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(due) < publishBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/config"
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/helpers"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	FeedHot = "hot"
+	FeedNew = "new"
+	FeedTop = "top"
+
+	// feedCandidates caps how many posts one cached feed holds.
+	feedCandidates = 500
+	FeedPerPage    = 25
+)
+
+// topWindows are the ?t= ranges for the top feed.
+var topWindows = map[string]time.Duration{
+	"hour":  time.Hour,
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+	"all":   0,
+}
+
+type cachedFeed struct {
+	PostIDs     []string  `json:"post_ids"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+func homeFeedKey(userId string, sortBy string, window string) string {
+	if sortBy == FeedTop {
+		return "feed:home:" + userId + ":" + sortBy + ":" + window
+	}
+	return "feed:home:" + userId + ":" + sortBy
+}
+
+// NormalizeFeedSort falls back to hot and day for unknown options.
+func NormalizeFeedSort(sortBy string, window string) (string, string) {
+	if sortBy != FeedNew && sortBy != FeedTop {
+		sortBy = FeedHot
+	}
+	if _, ok := topWindows[window]; !ok {
+		window = "day"
+	}
+	return sortBy, window
+}
+
+// HomeFeed returns one page of the user's home feed. The ranked list of post
+// ids is built on read and cached per user for config.FeedCacheTTL, so a
+// feed is at most that stale unless InvalidateHomeFeed clears it sooner.
+func HomeFeed(ctx context.Context, userId string, sortBy string, window string, page int) (models.FeedPage, error) {
+	sortBy, window = NormalizeFeedSort(sortBy, window)
+	if page < 1 {
+		page = 1
+	}
+
+	key := homeFeedKey(userId, sortBy, window)
+
+	var feed cachedFeed
+	data, err := config.Redis.Get(ctx, key).Bytes()
+	if err == nil {
+		err = json.Unmarshal(data, &feed)
+	}
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			fmt.Println("Error reading cached home feed:", err.Error())
+		}
+
+		feed, err = buildHomeFeed(ctx, userId, sortBy, window)
+		if err != nil {
+			return models.FeedPage{}, err
+		}
+
+		if data, err := json.Marshal(feed); err == nil {
+			if err := config.Redis.Set(ctx, key, data, config.FeedCacheTTL()).Err(); err != nil {
+				fmt.Println("Error caching home feed:", err.Error())
+			}
+		}
+	}
+
+	start := min((page-1)*FeedPerPage, len(feed.PostIDs))
+	end := min(start+FeedPerPage, len(feed.PostIDs))
+
+	posts, err := FindPostsByIDs(ctx, feed.PostIDs[start:end])
+	if err != nil {
+		return models.FeedPage{}, err
+	}
+
+	return models.FeedPage{
+		Posts:       posts,
+		Page:        page,
+		HasMore:     end < len(feed.PostIDs),
+		GeneratedAt: feed.GeneratedAt,
+	}, nil
+}
+
+// InvalidateHomeFeed drops every cached variant of a user's home feed, for
+// changes the user expects to see straight away such as hiding a post.
+func InvalidateHomeFeed(ctx context.Context, userIds ...string) {
+	var keys []string
+	for _, userId := range userIds {
+		keys = append(keys, homeFeedKey(userId, FeedHot, ""), homeFeedKey(userId, FeedNew, ""))
+		for window := range topWindows {
+			keys = append(keys, homeFeedKey(userId, FeedTop, window))
+		}
+	}
+
+	if len(keys) == 0 {
+		return
+	}
+	if err := config.Redis.Del(ctx, keys...).Err(); err != nil {
+		fmt.Println("Error invalidating home feed:", err.Error())
+	}
+}
+
+// HomeFeedFilter matches posts in the user's joined subreddits or by users
+// they follow, minus hidden posts and blocked authors.
+func HomeFeedFilter(ctx context.Context, userId string) (bson.M, error) {
+	var subredditIds []string
+	if err := database.MemberCollection.Distinct(ctx, "subreddit_id", bson.M{"user_id": userId}).Decode(&subredditIds); err != nil {
+		return nil, err
+	}
+
+	var followeeIds []string
+	if err := database.FollowCollection.Distinct(ctx, "followee_id", bson.M{"follower_id": userId}).Decode(&followeeIds); err != nil {
+		return nil, err
+	}
+
+	if len(subredditIds) == 0 && len(followeeIds) == 0 {
+		return nil, nil
+	}
+
+	blocked, err := BlockedUserIDs(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden, err := HiddenPostIDs(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"$or": []bson.M{
+			{"subreddit_id": bson.M{"$in": subredditIds}},
+			{"author_url": bson.M{"$in": followeeIds}},
+		},
+	}
+	if len(blocked) > 0 {
+		filter["author_url"] = bson.M{"$nin": blocked}
+	}
+	if len(hidden) > 0 {
+		filter["post_id"] = bson.M{"$nin": hidden}
+	}
+
+	return filter, nil
+}
+
+func buildHomeFeed(ctx context.Context, userId string, sortBy string, window string) (cachedFeed, error) {
+	feed := cachedFeed{PostIDs: []string{}, GeneratedAt: time.Now()}
+
+	filter, err := HomeFeedFilter(ctx, userId)
+	if err != nil || filter == nil {
+		return feed, err
+	}
+
+	findOptions := options.Find().
+		SetLimit(feedCandidates).
+		SetProjection(bson.M{"post_id": 1, "score": 1, "created_at": 1})
+
+	switch sortBy {
+	case FeedTop:
+		if duration := topWindows[window]; duration > 0 {
+			filter["created_at"] = bson.M{"$gte": time.Now().Add(-duration)}
+		}
+		findOptions.SetSort(bson.D{{Key: "score", Value: -1}, {Key: "created_at", Value: -1}})
+	default:
+		// Hot ranks the newest candidates, since older posts cannot outrank them
+		// without a very large score
+		findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	}
+
+	cursor, err := database.PostCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return feed, err
+	}
+	defer cursor.Close(ctx)
+
+	var posts []models.Post
+	if err := cursor.All(ctx, &posts); err != nil {
+		return feed, err
+	}
+
+	if sortBy == FeedHot {
+		sort.SliceStable(posts, func(i, j int) bool {
+			return helpers.HotScore(posts[i].Score, posts[i].CreatedAt) > helpers.HotScore(posts[j].Score, posts[j].CreatedAt)
+		})
+	}
+
+	for _, post := range posts {
+		feed.PostIDs = append(feed.PostIDs, post.PostID)
+	}
+
+	return feed, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrFollowSelf    = errors.New("you cannot follow yourself")
+	ErrFollowBlocked = errors.New("you cannot follow this user")
+)
+
+// Follow makes followerId follow followeeId. Following twice is a no-op, so
+// the counters only move when a new follow is created.
+func Follow(ctx context.Context, followerId string, followeeId string) (bool, error) {
+	if followerId == followeeId {
+		return false, ErrFollowSelf
+	}
+
+	blocked, err := IsBlocked(ctx, followerId, followeeId)
+	if err != nil {
+		return false, err
+	}
+	if blocked {
+		return false, ErrFollowBlocked
+	}
+
+	created := false
+	err = database.WithTransaction(ctx, func(ctx context.Context) error {
+		created = false
+
+		count, err := database.UserCollection.CountDocuments(ctx, bson.M{"user_id": followeeId})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return mongo.ErrNoDocuments
+		}
+
+		result, err := database.FollowCollection.UpdateOne(
+			ctx,
+			bson.M{"follower_id": followerId, "followee_id": followeeId},
+			bson.M{"$setOnInsert": bson.M{"created_at": time.Now()}},
+			options.UpdateOne().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+		if result.UpsertedCount == 0 {
+			return nil
+		}
+
+		created = true
+		return updateFollowCounts(ctx, followerId, followeeId, 1)
+	})
+
+	return created, err
+}
+
+// Unfollow removes a follow, moving the counters only if one existed.
+func Unfollow(ctx context.Context, followerId string, followeeId string) (bool, error) {
+	removed := false
+	err := database.WithTransaction(ctx, func(ctx context.Context) error {
+		removed = false
+
+		result, err := database.FollowCollection.DeleteOne(ctx, bson.M{"follower_id": followerId, "followee_id": followeeId})
+		if err != nil {
+			return err
+		}
+		if result.DeletedCount == 0 {
+			return nil
+		}
+
+		removed = true
+		return updateFollowCounts(ctx, followerId, followeeId, -1)
+	})
+
+	return removed, err
+}
+
+func updateFollowCounts(ctx context.Context, followerId string, followeeId string, delta int) error {
+	_, err := database.UserCollection.UpdateOne(ctx, bson.M{"user_id": followerId}, bson.M{"$inc": bson.M{"following_count": delta}})
+	if err != nil {
+		return err
+	}
+	_, err = database.UserCollection.UpdateOne(ctx, bson.M{"user_id": followeeId}, bson.M{"$inc": bson.M{"followers_count": delta}})
+	return err
+}
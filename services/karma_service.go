@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const karmaBatchSize = 500
+
+// GetKarma returns a user's total karma and their karma in every subreddit
+// they have been voted on in.
+func GetKarma(ctx context.Context, userId string) (models.KarmaDTO, error) {
+	var user models.User
+	findOptions := options.FindOne().SetProjection(bson.M{"user_id": 1, "post_karma": 1, "comment_karma": 1})
+	if err := database.UserCollection.FindOne(ctx, bson.M{"user_id": userId}, findOptions).Decode(&user); err != nil {
+		return models.KarmaDTO{}, err
+	}
+
+	karma := models.KarmaDTO{
+		UserID:       user.UserId,
+		PostKarma:    user.PostKarma,
+		CommentKarma: user.CommentKarma,
+		TotalKarma:   user.PostKarma + user.CommentKarma,
+		Subreddits:   []models.SubredditKarma{},
+	}
+
+	cursor, err := database.SubredditKarmaCollection.Find(ctx, bson.M{"user_id": userId}, options.Find().SetSort(bson.D{{Key: "post_karma", Value: -1}}))
+	if err != nil {
+		return karma, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &karma.Subreddits); err != nil {
+		return karma, err
+	}
+
+	return karma, nil
+}
+
+// SubredditKarma returns the karma a user has earned inside one subreddit.
+func SubredditKarma(ctx context.Context, userId string, subredditId string) (int, error) {
+	var karma models.SubredditKarma
+	err := database.SubredditKarmaCollection.FindOne(ctx, bson.M{"user_id": userId, "subreddit_id": subredditId}).Decode(&karma)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return karma.PostKarma + karma.CommentKarma, nil
+}
+
+type karmaKey struct {
+	UserID      string `bson:"author_id"`
+	SubredditID string `bson:"subreddit_id"`
+}
+
+type karmaTotal struct {
+	Key   karmaKey `bson:"_id"`
+	Total int      `bson:"total"`
+}
+
+type karmaCounts struct {
+	post    int
+	comment int
+}
+
+func sumVotes(ctx context.Context, collection *mongo.Collection, filter bson.M) ([]karmaTotal, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"author_id": "$author_id", "subreddit_id": "$subreddit_id"},
+			"total": bson.M{"$sum": "$value"},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals []karmaTotal
+	err = cursor.All(ctx, &totals)
+	return totals, err
+}
+
+// ReconcileKarma recomputes every user's karma from the vote records and
+// repairs counters that have drifted. Votes cast while it runs may be
+// overwritten and are corrected on the next run.
+func ReconcileKarma(ctx context.Context) error {
+	startedAt := time.Now()
+
+	postTotals, err := sumVotes(ctx, database.PostVoteCollection, bson.M{})
+	if err != nil {
+		return err
+	}
+	commentTotals, err := sumVotes(ctx, database.CommentVoteCollection, bson.M{})
+	if err != nil {
+		return err
+	}
+
+	users := make(map[string]karmaCounts)
+	subreddits := make(map[karmaKey]karmaCounts)
+
+	for _, total := range postTotals {
+		counts := users[total.Key.UserID]
+		counts.post += total.Total
+		users[total.Key.UserID] = counts
+
+		counts = subreddits[total.Key]
+		counts.post += total.Total
+		subreddits[total.Key] = counts
+	}
+	for _, total := range commentTotals {
+		counts := users[total.Key.UserID]
+		counts.comment += total.Total
+		users[total.Key.UserID] = counts
+
+		counts = subreddits[total.Key]
+		counts.comment += total.Total
+		subreddits[total.Key] = counts
+	}
+
+	if err := reconcileUserKarma(ctx, users); err != nil {
+		return err
+	}
+
+	var writes []mongo.WriteModel
+	for key, counts := range subreddits {
+		if key.UserID == "" || key.SubredditID == "" {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"user_id": key.UserID, "subreddit_id": key.SubredditID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"post_karma":    counts.post,
+				"comment_karma": counts.comment,
+				"updated_at":    startedAt,
+			}}).
+			SetUpsert(true))
+	}
+	if err := bulkWrite(ctx, database.SubredditKarmaCollection, writes); err != nil {
+		return err
+	}
+
+	// Anything not touched above no longer has any votes behind it
+	_, err = database.SubredditKarmaCollection.DeleteMany(ctx, bson.M{"updated_at": bson.M{"$lt": startedAt}})
+	return err
+}
+
+func reconcileUserKarma(ctx context.Context, users map[string]karmaCounts) error {
+	findOptions := options.Find().SetProjection(bson.M{"user_id": 1, "post_karma": 1, "comment_karma": 1})
+
+	cursor, err := database.UserCollection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var writes []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+
+		counts := users[user.UserId]
+		if user.PostKarma == counts.post && user.CommentKarma == counts.comment {
+			continue
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"user_id": user.UserId}).
+			SetUpdate(bson.M{"$set": bson.M{"post_karma": counts.post, "comment_karma": counts.comment}}))
+
+		if len(writes) == karmaBatchSize {
+			if err := bulkWrite(ctx, database.UserCollection, writes); err != nil {
+				return err
+			}
+			writes = nil
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return bulkWrite(ctx, database.UserCollection, writes)
+}
+
+func bulkWrite(ctx context.Context, collection *mongo.Collection, writes []mongo.WriteModel) error {
+	for start := 0; start < len(writes); start += karmaBatchSize {
+		end := min(start+karmaBatchSize, len(writes))
+		if _, err := collection.BulkWrite(ctx, writes[start:end], options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/links"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// LinkFetcher downloads pages for link previews.
+var LinkFetcher = links.NewFetcher()
+
+// previewReuseWindow is how recent another post's preview of the same URL
+// must be to copy it instead of fetching the page again.
+const previewReuseWindow = 24 * time.Hour
+
+// PrepareLinkPost stores the canonical form and domain of a link post's URL
+// and leaves its preview pending for UnfurlPost.
+func PrepareLinkPost(post *models.Post) error {
+	// Only link posts, or untyped posts with a URL, keep the URL
+	if post.Type != models.LINK_POST && (post.Type != "" || post.URL == "") {
+		post.URL = ""
+		return nil
+	}
+
+	canonical, domain, err := links.Normalize(post.URL)
+	if err != nil {
+		return err
+	}
+
+	post.Type = models.LINK_POST
+	post.URL = canonical
+	post.Domain = domain
+	post.LinkPreview = &models.LinkPreview{Status: models.PREVIEW_PENDING}
+	return nil
+}
+
+// UnfurlPost fills in the preview of a link post. Pages that are blocked,
+// not HTML or missing are marked FAILED; network errors, rate limits and
+// server errors are returned so the job is retried.
+func UnfurlPost(ctx context.Context, fetcher *links.Fetcher, postId string) error {
+	var post models.Post
+	if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": postId}).Decode(&post); err != nil {
+		return err
+	}
+
+	if post.Type != models.LINK_POST || post.URL == "" {
+		return nil
+	}
+	if post.LinkPreview != nil && post.LinkPreview.Status == models.PREVIEW_READY {
+		return nil
+	}
+
+	preview, err := recentPreview(ctx, post)
+	if err != nil {
+		return err
+	}
+
+	if preview == nil {
+		fetched, err := fetcher.Fetch(ctx, post.URL)
+		var statusErr *links.StatusError
+		switch {
+		case errors.As(err, &statusErr) && statusErr.Temporary():
+			return err
+		case errors.Is(err, links.ErrBlockedAddress), errors.Is(err, links.ErrNotHTML), errors.Is(err, links.ErrInvalidURL), errors.Is(err, links.ErrBadStatus):
+			return setPreview(ctx, postId, models.LinkPreview{Status: models.PREVIEW_FAILED, Error: err.Error()})
+		case err != nil:
+			return err
+		}
+
+		preview = &models.LinkPreview{
+			Title:       fetched.Title,
+			Description: fetched.Description,
+			ImageURL:    fetched.ImageURL,
+			SiteName:    fetched.SiteName,
+			Status:      models.PREVIEW_READY,
+		}
+	}
+
+	now := time.Now()
+	preview.FetchedAt = &now
+	return setPreview(ctx, postId, *preview)
+}
+
+// recentPreview returns a ready preview another post fetched for the same
+// URL within previewReuseWindow.
+func recentPreview(ctx context.Context, post models.Post) (*models.LinkPreview, error) {
+	filter := bson.M{
+		"url":                     post.URL,
+		"post_id":                 bson.M{"$ne": post.PostID},
+		"link_preview.status":     models.PREVIEW_READY,
+		"link_preview.fetched_at": bson.M{"$gte": time.Now().Add(-previewReuseWindow)},
+	}
+	findOptions := options.FindOne().SetProjection(bson.M{"link_preview": 1})
+
+	var other models.Post
+	err := database.PostCollection.FindOne(ctx, filter, findOptions).Decode(&other)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return other.LinkPreview, nil
+}
+
+func setPreview(ctx context.Context, postId string, preview models.LinkPreview) error {
+	_, err := database.PostCollection.UpdateOne(
+		ctx,
+		bson.M{"post_id": postId},
+		bson.M{"$set": bson.M{"link_preview": preview}},
+	)
+	return err
+}
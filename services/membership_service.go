@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func IsModerator(ctx context.Context, userId string, subredditId string) (bool, error) {
+	count, err := database.MemberCollection.CountDocuments(ctx, bson.M{
+		"user_id":      userId,
+		"subreddit_id": subredditId,
+		"role":         "MODERATOR",
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ModeratedSubredditIDs lists the subreddits a user moderates.
+func ModeratedSubredditIDs(ctx context.Context, userId string) ([]string, error) {
+	var subredditIds []string
+	err := database.MemberCollection.Distinct(ctx, "subreddit_id", bson.M{"user_id": userId, "role": "MODERATOR"}).Decode(&subredditIds)
+	return subredditIds, err
+}
+
+// ModeratorIDs lists the moderators of a subreddit.
+func ModeratorIDs(ctx context.Context, subredditId string) ([]string, error) {
+	var userIds []string
+	err := database.MemberCollection.Distinct(ctx, "user_id", bson.M{"subreddit_id": subredditId, "role": "MODERATOR"}).Decode(&userIds)
+	return userIds, err
+}
+
+// upsertMembership creates or updates the user's membership in one
+// subreddit and counts the new member if one was created. It returns the
+// membership and whether anything changed.
+func upsertMembership(ctx context.Context, userId string, subredditId string, update bson.M) (models.SubRedditMembers, bool, error) {
+	var member models.SubRedditMembers
+	var changed bool
+
+	err := database.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := database.SubredditCollection.FindOne(ctx, bson.M{"subreddit_id": subredditId}).Err(); err != nil {
+			return err
+		}
+
+		filter := bson.M{"user_id": userId, "subreddit_id": subredditId}
+		result, err := database.MemberCollection.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+		changed = result.UpsertedCount > 0 || result.ModifiedCount > 0
+
+		if result.UpsertedCount > 0 {
+			_, err := database.SubredditCollection.UpdateOne(
+				ctx,
+				bson.M{"subreddit_id": subredditId},
+				bson.M{"$inc": bson.M{"members_count": 1}},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return database.MemberCollection.FindOne(ctx, filter).Decode(&member)
+	})
+
+	return member, changed, err
+}
+
+// JoinSubreddit makes the user a member of the subreddit. Joining again is
+// a no-op that returns the existing membership.
+func JoinSubreddit(ctx context.Context, userId string, subredditId string) (models.SubRedditMembers, bool, error) {
+	update := bson.M{"$setOnInsert": bson.M{
+		"member_id": bson.NewObjectID().Hex(),
+		"role":      "MEMBER",
+		"joined_at": time.Now(),
+	}}
+	return upsertMembership(ctx, userId, subredditId, update)
+}
+
+// AddModerator makes the user a moderator of the subreddit, joining them to
+// it first if needed. It reports false when they already moderate it.
+func AddModerator(ctx context.Context, userId string, subredditId string) (models.SubRedditMembers, bool, error) {
+	update := bson.M{
+		"$set": bson.M{"role": "MODERATOR"},
+		"$setOnInsert": bson.M{
+			"member_id": bson.NewObjectID().Hex(),
+			"joined_at": time.Now(),
+		},
+	}
+	return upsertMembership(ctx, userId, subredditId, update)
+}
+
+// LeaveSubreddit removes the user's membership in the subreddit. Leaving a
+// subreddit the user is not in is a no-op and reports false.
+func LeaveSubreddit(ctx context.Context, userId string, subredditId string) (bool, error) {
+	var left bool
+
+	err := database.WithTransaction(ctx, func(ctx context.Context) error {
+		result, err := database.MemberCollection.DeleteOne(ctx, bson.M{"user_id": userId, "subreddit_id": subredditId})
+		if err != nil {
+			return err
+		}
+		left = result.DeletedCount > 0
+		if !left {
+			return nil
+		}
+
+		_, err = database.SubredditCollection.UpdateOne(
+			ctx,
+			bson.M{"subreddit_id": subredditId},
+			bson.M{"$inc": bson.M{"members_count": -1}},
+		)
+		return err
+	})
+
+	return left, err
+}
+
+// RecountMembers sets every subreddit's members_count from its memberships.
+func RecountMembers(ctx context.Context) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$subreddit_id", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := database.MemberCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var counts []struct {
+		SubredditID string `bson:"_id"`
+		Count       int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return err
+	}
+
+	subredditIds := make([]string, 0, len(counts))
+	for _, count := range counts {
+		_, err := database.SubredditCollection.UpdateOne(
+			ctx,
+			bson.M{"subreddit_id": count.SubredditID},
+			bson.M{"$set": bson.M{"members_count": count.Count}},
+		)
+		if err != nil {
+			return err
+		}
+		subredditIds = append(subredditIds, count.SubredditID)
+	}
+
+	_, err = database.SubredditCollection.UpdateMany(
+		ctx,
+		bson.M{"subreddit_id": bson.M{"$nin": subredditIds}},
+		bson.M{"$set": bson.M{"members_count": 0}},
+	)
+	return err
+}
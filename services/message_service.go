@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"github.com/EsanSamuel/Reddit_Clone/models"
+)
+
+// MaxGroupParticipants caps group conversations, including the creator.
+const MaxGroupParticipants = 10
+
+// CanAccessConversation allows participants, and for modmail every
+// moderator of the subreddit.
+func CanAccessConversation(ctx context.Context, userId string, conversation models.Conversation) (bool, error) {
+	if slices.Contains(conversation.ParticipantIDs, userId) {
+		return true, nil
+	}
+
+	if conversation.Type == models.ConversationModmail {
+		return IsModerator(ctx, userId, conversation.SubredditID)
+	}
+
+	return false, nil
+}
+
+// CanMessage rejects senders who blocked, or are blocked by, any other
+// participant. Modmail is exempt so users can always reach moderators.
+func CanMessage(ctx context.Context, senderId string, conversation models.Conversation) (bool, error) {
+	if conversation.Type == models.ConversationModmail {
+		return true, nil
+	}
+
+	for _, participantId := range conversation.ParticipantIDs {
+		if participantId == senderId {
+			continue
+		}
+		blocked, err := IsBlocked(ctx, senderId, participantId)
+		if err != nil {
+			return false, err
+		}
+		if blocked {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// ConversationRecipients lists who should hear about a new message.
+func ConversationRecipients(ctx context.Context, conversation models.Conversation) []string {
+	recipients := slices.Clone(conversation.ParticipantIDs)
+
+	if conversation.Type == models.ConversationModmail {
+		moderatorIds, err := ModeratorIDs(ctx, conversation.SubredditID)
+		if err == nil {
+			for _, moderatorId := range moderatorIds {
+				if !slices.Contains(recipients, moderatorId) {
+					recipients = append(recipients, moderatorId)
+				}
+			}
+		}
+	}
+
+	return recipients
+}
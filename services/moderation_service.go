@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/config"
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MaxStickiedPosts is how many posts a subreddit can pin at once.
+const MaxStickiedPosts = 2
+
+const archiveBatchSize = 500
+
+var (
+	ErrPostLocked    = errors.New("this thread is locked")
+	ErrPostArchived  = errors.New("this post is archived")
+	ErrNotModerator  = errors.New("only moderators can do this")
+	ErrStickyLimit   = errors.New("a subreddit can have at most 2 stickied posts")
+	ErrStickyProfile = errors.New("only subreddit posts can be stickied")
+)
+
+func newModLogEntry(subredditId string, moderatorId string, action string, targetType string, targetId string, details string) models.ModLogEntry {
+	return models.ModLogEntry{
+		LogID:       bson.NewObjectID().Hex(),
+		SubredditID: subredditId,
+		ModeratorID: moderatorId,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetId,
+		Details:     details,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// LogModAction records a moderation action in the subreddit's modlog.
+func LogModAction(ctx context.Context, subredditId string, moderatorId string, action string, targetType string, targetId string, details string) error {
+	_, err := database.ModLogCollection.InsertOne(ctx, newModLogEntry(subredditId, moderatorId, action, targetType, targetId, details))
+	return err
+}
+
+// CheckCommentable returns an error when the post does not exist or no
+// longer accepts comments.
+func CheckCommentable(ctx context.Context, postId string) error {
+	var post models.Post
+	findOptions := options.FindOne().SetProjection(bson.M{"locked": 1, "archived": 1})
+	if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": postId}, findOptions).Decode(&post); err != nil {
+		return err
+	}
+
+	if post.Archived {
+		return ErrPostArchived
+	}
+	if post.Locked {
+		return ErrPostLocked
+	}
+	return nil
+}
+
+// flagChange is one post flag a moderation request sets.
+type flagChange struct {
+	field     string
+	value     bool
+	current   bool
+	modOnly   bool
+	onAction  string
+	offAction string
+}
+
+// ModeratePost changes a post's lock, sticky, NSFW and spoiler flags and
+// logs each change. Locking and stickying are for moderators and admins;
+// authors can also mark their own posts NSFW or spoiler.
+func ModeratePost(ctx context.Context, actorId string, role string, postId string, dto models.PostModerationDTO) (models.Post, error) {
+	var post models.Post
+
+	err := database.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": postId}).Decode(&post); err != nil {
+			return err
+		}
+
+		moderator := role == "ADMIN"
+		if !moderator && post.SubredditID != "" {
+			var err error
+			if moderator, err = IsModerator(ctx, actorId, post.SubredditID); err != nil {
+				return err
+			}
+		}
+
+		var changes []flagChange
+		if dto.Locked != nil {
+			changes = append(changes, flagChange{"locked", *dto.Locked, post.Locked, true, models.MOD_LOCK, models.MOD_UNLOCK})
+		}
+		if dto.Stickied != nil {
+			changes = append(changes, flagChange{"stickied", *dto.Stickied, post.Stickied, true, models.MOD_STICKY, models.MOD_UNSTICKY})
+		}
+		if dto.NSFW != nil {
+			changes = append(changes, flagChange{"nsfw", *dto.NSFW, post.NSFW, false, models.MOD_MARK_NSFW, models.MOD_UNMARK_NSFW})
+		}
+		if dto.Spoiler != nil {
+			changes = append(changes, flagChange{"spoiler", *dto.Spoiler, post.Spoiler, false, models.MOD_MARK_SPOILER, models.MOD_UNMARK_SPOILER})
+		}
+
+		set := bson.M{}
+		var entries []any
+		for _, change := range changes {
+			if change.modOnly && !moderator {
+				return ErrNotModerator
+			}
+			if !moderator && post.AuthorID != actorId {
+				return ErrNotModerator
+			}
+			if change.value == change.current {
+				continue
+			}
+
+			if change.field == "stickied" {
+				if err := countSticky(ctx, post, change.value); err != nil {
+					return err
+				}
+			}
+
+			action := change.offAction
+			if change.value {
+				action = change.onAction
+			}
+			set[change.field] = change.value
+			entries = append(entries, newModLogEntry(post.SubredditID, actorId, action, "post", post.PostID, ""))
+		}
+
+		if len(set) == 0 {
+			return nil
+		}
+		set["updated_at"] = time.Now()
+
+		err := database.PostCollection.FindOneAndUpdate(
+			ctx,
+			bson.M{"post_id": postId},
+			bson.M{"$set": set},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&post)
+		if err != nil {
+			return err
+		}
+
+		_, err = database.ModLogCollection.InsertMany(ctx, entries)
+		return err
+	})
+	if err != nil {
+		return post, fmt.Errorf("moderating post %s: %w", postId, err)
+	}
+
+	return post, nil
+}
+
+// countSticky takes one of the subreddit's sticky slots, or gives it back
+// when the post is unstickied. The guarded update on the subreddit makes
+// concurrent stickies in one subreddit conflict, so the limit holds.
+func countSticky(ctx context.Context, post models.Post, stickied bool) error {
+	if post.SubredditID == "" {
+		if stickied {
+			return ErrStickyProfile
+		}
+		return nil
+	}
+
+	filter := bson.M{"subreddit_id": post.SubredditID, "stickied_count": bson.M{"$gt": 0}}
+	delta := -1
+	if stickied {
+		filter["stickied_count"] = bson.M{"$lt": MaxStickiedPosts}
+		delta = 1
+	}
+
+	result, err := database.SubredditCollection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"stickied_count": delta}})
+	if err != nil {
+		return err
+	}
+	if stickied && result.MatchedCount == 0 {
+		return ErrStickyLimit
+	}
+	return nil
+}
+
+// RecountStickied sets every subreddit's stickied_count from its posts.
+func RecountStickied(ctx context.Context) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"stickied": true, "subreddit_id": bson.M{"$ne": ""}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$subreddit_id", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := database.PostCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var counts []struct {
+		SubredditID string `bson:"_id"`
+		Count       int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return err
+	}
+
+	subredditIds := make([]string, 0, len(counts))
+	for _, count := range counts {
+		_, err := database.SubredditCollection.UpdateOne(
+			ctx,
+			bson.M{"subreddit_id": count.SubredditID},
+			bson.M{"$set": bson.M{"stickied_count": count.Count}},
+		)
+		if err != nil {
+			return err
+		}
+		subredditIds = append(subredditIds, count.SubredditID)
+	}
+
+	_, err = database.SubredditCollection.UpdateMany(
+		ctx,
+		bson.M{"subreddit_id": bson.M{"$nin": subredditIds}},
+		bson.M{"$set": bson.M{"stickied_count": 0}},
+	)
+	return err
+}
+
+// ArchiveOldPosts archives posts older than config.PostArchiveAge, logging
+// each one in its subreddit's modlog as a system action.
+func ArchiveOldPosts(ctx context.Context) error {
+	cutoff := time.Now().Add(-config.PostArchiveAge())
+	filter := bson.M{"archived": bson.M{"$ne": true}, "created_at": bson.M{"$lt": cutoff}}
+	findOptions := options.Find().
+		SetProjection(bson.M{"post_id": 1, "subreddit_id": 1}).
+		SetLimit(archiveBatchSize)
+
+	for {
+		cursor, err := database.PostCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			return err
+		}
+
+		var posts []models.Post
+		if err := cursor.All(ctx, &posts); err != nil {
+			return err
+		}
+		if len(posts) == 0 {
+			return nil
+		}
+
+		postIds := make([]string, 0, len(posts))
+		entries := make([]any, 0, len(posts))
+		for _, post := range posts {
+			postIds = append(postIds, post.PostID)
+			entries = append(entries, newModLogEntry(post.SubredditID, models.SYSTEM_MODERATOR, models.MOD_ARCHIVE, "post", post.PostID, ""))
+		}
+
+		err = database.WithTransaction(ctx, func(ctx context.Context) error {
+			update := bson.M{"$set": bson.M{"archived": true, "updated_at": time.Now()}}
+			if _, err := database.PostCollection.UpdateMany(ctx, bson.M{"post_id": bson.M{"$in": postIds}}, update); err != nil {
+				return err
+			}
+
+			_, err := database.ModLogCollection.InsertMany(ctx, entries)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(posts) < archiveBatchSize {
+			return nil
+		}
+	}
+}
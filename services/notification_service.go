@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/realtime"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ScoreMilestones are the post scores that notify the author when crossed.
+var ScoreMilestones = []int{10, 50, 100, 500, 1000, 5000, 10000}
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_/])u/([A-Za-z0-9_-]{3,20})`)
+
+// Preference returns the user's setting for a notification type. In-app
+// notifications are on and emails are off unless the user changed them.
+func Preference(user models.User, notificationType string) models.NotificationPreference {
+	if preference, ok := user.NotificationPreferences[notificationType]; ok {
+		return preference
+	}
+	return models.NotificationPreference{InApp: true, Email: false}
+}
+
+// Notify stores a notification for its recipient, skipping users acting on
+// their own content and users who turned the type off. A notification the
+// user only wants by email is stored for the digest but kept out of the
+// inbox and realtime stream.
+func Notify(ctx context.Context, notification models.Notification) error {
+	if notification.UserID == "" || notification.UserID == notification.ActorID {
+		return nil
+	}
+
+	var recipient models.User
+	err := database.UserCollection.FindOne(ctx, bson.M{"user_id": notification.UserID}).Decode(&recipient)
+	if err != nil {
+		return err
+	}
+
+	preference := Preference(recipient, notification.Type)
+	if !preference.InApp && !preference.Email {
+		return nil
+	}
+
+	notification.NotificationID = bson.NewObjectID().Hex()
+	notification.Read = false
+	notification.Emailed = false
+	notification.InApp = preference.InApp
+	notification.CreatedAt = time.Now()
+
+	if _, err := database.NotificationCollection.InsertOne(ctx, notification); err != nil {
+		return err
+	}
+
+	if !notification.InApp {
+		return nil
+	}
+
+	realtime.PublishAll(ctx, realtime.NotificationCreated, notification, realtime.UserChannel(notification.UserID))
+	return nil
+}
+
+// InboxFilter matches the user's in-app notifications. Notifications stored
+// before in_app was recorded are all in-app.
+func InboxFilter(userId string) bson.M {
+	return bson.M{"user_id": userId, "in_app": bson.M{"$ne": false}}
+}
+
+// NotifyMentions notifies every user mentioned as u/username in text.
+func NotifyMentions(ctx context.Context, actorId string, text string, postId string, commentId string) error {
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username := match[1]
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+
+		var user models.User
+		findOptions := options.FindOne().SetCollation(UsernameCollation)
+		if err := database.UserCollection.FindOne(ctx, bson.M{"username": username}, findOptions).Decode(&user); err != nil {
+			continue
+		}
+
+		err := Notify(ctx, models.Notification{
+			UserID:    user.UserId,
+			ActorID:   actorId,
+			Type:      models.NotificationMention,
+			PostID:    postId,
+			CommentID: commentId,
+			Message:   ActorName(ctx, actorId) + " mentioned you",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NotifyScoreMilestone notifies the author when a post first reaches one
+// of the milestones. Reached milestones are recorded on the post, so a
+// score that dips and climbs back does not notify again.
+func NotifyScoreMilestone(ctx context.Context, post models.Post) error {
+	var reached []int
+	for _, milestone := range ScoreMilestones {
+		if post.Score >= milestone && !slices.Contains(post.ScoreMilestones, milestone) {
+			reached = append(reached, milestone)
+		}
+	}
+	if len(reached) == 0 {
+		return nil
+	}
+
+	// Only the highest new milestone is announced
+	milestone := reached[len(reached)-1]
+	result, err := database.PostCollection.UpdateOne(
+		ctx,
+		bson.M{"post_id": post.PostID, "score_milestones": bson.M{"$ne": milestone}},
+		bson.M{"$addToSet": bson.M{"score_milestones": bson.M{"$each": reached}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return nil
+	}
+
+	return Notify(ctx, models.Notification{
+		UserID:      post.AuthorID,
+		Type:        models.NotificationScoreMilestone,
+		PostID:      post.PostID,
+		SubredditID: post.SubredditID,
+		Message:     fmt.Sprintf("Your post \"%s\" reached %d points", post.Title, milestone),
+	})
+}
+
+// ActorName is how a user is referred to in notification messages.
+func ActorName(ctx context.Context, userId string) string {
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user); err != nil {
+		return "Someone"
+	}
+	if user.Username != "" {
+		return "u/" + user.Username
+	}
+	return user.FirstName
+}
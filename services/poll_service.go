@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	defaultPollDuration = 3 * 24 * time.Hour
+	minPollDuration     = time.Hour
+	maxPollDuration     = 7 * 24 * time.Hour
+	maxPollOptionLength = 120
+)
+
+var (
+	ErrInvalidPoll       = errors.New("polls need 2 to 6 distinct options of up to 120 characters")
+	ErrInvalidPollClose  = errors.New("polls must close between 1 hour and 7 days from now")
+	ErrNotPoll           = errors.New("post is not a poll")
+	ErrPollClosed        = errors.New("poll is closed")
+	ErrInvalidPollOption = errors.New("option is not part of this poll")
+	ErrAlreadyVoted      = errors.New("you have already voted in this poll")
+)
+
+// PreparePoll turns the poll options and closing time sent with a poll post
+// into its stored Poll. Without a closing time the poll runs for 3 days.
+func PreparePoll(post *models.Post) error {
+	if post.Type != models.POLL_POST {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	options := []models.PollOption{}
+	for _, text := range post.PollOptions {
+		text = strings.TrimSpace(text)
+		if text == "" || len([]rune(text)) > maxPollOptionLength || seen[strings.ToLower(text)] {
+			return ErrInvalidPoll
+		}
+		seen[strings.ToLower(text)] = true
+		options = append(options, models.PollOption{OptionID: bson.NewObjectID().Hex(), Text: text})
+	}
+	if len(options) < models.MIN_POLL_OPTIONS || len(options) > models.MAX_POLL_OPTIONS {
+		return ErrInvalidPoll
+	}
+
+	closesAt := post.PollClosesAt
+	if closesAt.IsZero() {
+		closesAt = time.Now().Add(defaultPollDuration)
+	}
+	if until := time.Until(closesAt); until < minPollDuration || until > maxPollDuration {
+		return ErrInvalidPollClose
+	}
+
+	post.Poll = &models.Poll{Options: options, ClosesAt: closesAt}
+	post.PollOptions = nil
+	post.PollClosesAt = time.Time{}
+	return nil
+}
+
+// VotePoll records a user's only vote in a poll and returns the results,
+// which are now visible to them.
+func VotePoll(ctx context.Context, userId string, postId string, optionId string) (models.PollSummary, error) {
+	var post models.Post
+
+	err := database.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": postId}).Decode(&post); err != nil {
+			return err
+		}
+
+		if post.Type != models.POLL_POST || post.Poll == nil {
+			return ErrNotPoll
+		}
+		if post.Archived {
+			return ErrPostArchived
+		}
+		if !time.Now().Before(post.Poll.ClosesAt) {
+			return ErrPollClosed
+		}
+		if !hasPollOption(post.Poll, optionId) {
+			return ErrInvalidPollOption
+		}
+
+		// Two first votes racing both update the post below, so one of the
+		// transactions conflicts and retries and then finds the other vote
+		voted, err := database.PollVoteCollection.CountDocuments(ctx, bson.M{"user_id": userId, "post_id": postId})
+		if err != nil {
+			return err
+		}
+		if voted > 0 {
+			return ErrAlreadyVoted
+		}
+
+		vote := models.PollVote{
+			PostID:    postId,
+			UserID:    userId,
+			OptionID:  optionId,
+			CreatedAt: time.Now(),
+		}
+		// The unique (user_id, post_id) index, once migrated, is a backstop
+		if _, err := database.PollVoteCollection.InsertOne(ctx, vote); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return ErrAlreadyVoted
+			}
+			return err
+		}
+
+		return database.PostCollection.FindOneAndUpdate(
+			ctx,
+			bson.M{"post_id": postId},
+			bson.M{"$inc": bson.M{"poll.options.$[option].votes": 1, "poll.total_votes": 1}},
+			options.FindOneAndUpdate().
+				SetArrayFilters([]any{bson.M{"option.option_id": optionId}}).
+				SetReturnDocument(options.After),
+		).Decode(&post)
+	})
+	if err != nil {
+		return models.PollSummary{}, fmt.Errorf("voting in poll %s: %w", postId, err)
+	}
+
+	return SummarizePoll(post.Poll, optionId), nil
+}
+
+func hasPollOption(poll *models.Poll, optionId string) bool {
+	for _, option := range poll.Options {
+		if option.OptionID == optionId {
+			return true
+		}
+	}
+	return false
+}
+
+// SummarizePoll builds the compact results for a user who voted for
+// votedOptionId, or has not voted when it is empty.
+func SummarizePoll(poll *models.Poll, votedOptionId string) models.PollSummary {
+	closed := !time.Now().Before(poll.ClosesAt)
+	visible := closed || votedOptionId != ""
+
+	summary := models.PollSummary{
+		Options:        make([]models.PollOptionResult, 0, len(poll.Options)),
+		TotalVotes:     poll.TotalVotes,
+		ClosesAt:       poll.ClosesAt,
+		Closed:         closed,
+		VotedOptionID:  votedOptionId,
+		ResultsVisible: visible,
+	}
+	for _, option := range poll.Options {
+		result := models.PollOptionResult{OptionID: option.OptionID, Text: option.Text}
+		if visible {
+			votes := option.Votes
+			result.Votes = &votes
+		}
+		summary.Options = append(summary.Options, result)
+	}
+
+	return summary
+}
+
+// AttachPollSummaries fills in PollSummary for the poll posts in posts,
+// looking up userId's votes in one query. userId may be empty.
+func AttachPollSummaries(ctx context.Context, userId string, posts []models.Post) error {
+	var pollIds []string
+	for _, post := range posts {
+		if post.Poll != nil {
+			pollIds = append(pollIds, post.PostID)
+		}
+	}
+	if len(pollIds) == 0 {
+		return nil
+	}
+
+	voted := map[string]string{}
+	if userId != "" {
+		cursor, err := database.PollVoteCollection.Find(ctx, bson.M{"user_id": userId, "post_id": bson.M{"$in": pollIds}})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		var votes []models.PollVote
+		if err := cursor.All(ctx, &votes); err != nil {
+			return err
+		}
+		for _, vote := range votes {
+			voted[vote.PostID] = vote.OptionID
+		}
+	}
+
+	for i := range posts {
+		if posts[i].Poll != nil {
+			summary := SummarizePoll(posts[i].Poll, voted[posts[i].PostID])
+			posts[i].PollSummary = &summary
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/media"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// DeletePost removes a post with its comments and everything that points
+// at them: votes and the karma they earned, poll votes, saves and hides.
+// Its media is released for cleanup, a stickied post frees its sticky slot
+// and a crosspost is taken off its original's count.
+func DeletePost(ctx context.Context, post models.Post) error {
+	err := database.WithTransaction(ctx, func(ctx context.Context) error {
+		filter := bson.M{"post_id": post.PostID}
+
+		if err := reverseKarma(ctx, filter); err != nil {
+			return err
+		}
+
+		if _, err := database.PostCollection.DeleteOne(ctx, filter); err != nil {
+			return err
+		}
+
+		for _, collection := range []*mongo.Collection{
+			database.CommentCollection,
+			database.PostVoteCollection,
+			database.CommentVoteCollection,
+			database.PollVoteCollection,
+			database.SavedCollection,
+			database.HiddenPostCollection,
+		} {
+			if _, err := collection.DeleteMany(ctx, filter); err != nil {
+				return err
+			}
+		}
+
+		if post.SubredditID != "" {
+			_, err := database.SubredditCollection.UpdateOne(
+				ctx,
+				bson.M{"subreddit_id": post.SubredditID},
+				bson.M{"$inc": bson.M{"posts_count": -1}},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if post.Stickied {
+			if err := countSticky(ctx, post, false); err != nil {
+				return err
+			}
+		}
+
+		if post.CrosspostParentID != "" {
+			_, err := database.PostCollection.UpdateOne(
+				ctx,
+				bson.M{"post_id": post.CrosspostParentID},
+				bson.M{
+					"$inc":  bson.M{"crosspost_count": -1},
+					"$pull": bson.M{"crossposted_to": post.SubredditID},
+				},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return media.Release(ctx, post.PostID, post.MediaIDs)
+	})
+	if err != nil {
+		return fmt.Errorf("deleting post %s: %w", post.PostID, err)
+	}
+	return nil
+}
+
+// reverseKarma takes back the karma earned by the post and comment votes
+// matching filter.
+func reverseKarma(ctx context.Context, filter bson.M) error {
+	postTotals, err := sumVotes(ctx, database.PostVoteCollection, filter)
+	if err != nil {
+		return err
+	}
+	for _, total := range postTotals {
+		if err := addKarma(ctx, total.Key.UserID, total.Key.SubredditID, "post_karma", -total.Total); err != nil {
+			return err
+		}
+	}
+
+	commentTotals, err := sumVotes(ctx, database.CommentVoteCollection, filter)
+	if err != nil {
+		return err
+	}
+	for _, total := range commentTotals {
+		if err := addKarma(ctx, total.Key.UserID, total.Key.SubredditID, "comment_karma", -total.Total); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/EsanSamuel/Reddit_Clone/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrInvalidUsername = errors.New("username must be 3-20 letters, digits, _ or -")
+	ErrUsernameTaken   = errors.New("username is already taken")
+)
+
+// Usernames use the same characters mentions match.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,20}$`)
+
+// UsernameCollation compares usernames case-insensitively, like the unique
+// username index.
+var UsernameCollation = &options.Collation{Locale: "en", Strength: 2}
+
+// CheckUsername returns an error when a username is malformed or already
+// taken by another user.
+func CheckUsername(ctx context.Context, username string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+
+	err := database.UserCollection.FindOne(ctx, bson.M{"username": username}, options.FindOne().SetCollation(UsernameCollation)).Err()
+	switch {
+	case err == nil:
+		return ErrUsernameTaken
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return nil
+	default:
+		return err
+	}
+}
+
+func PublicProfile(user models.User) models.PublicProfileDTO {
+	// Uploaded avatars are private objects, older ones are plain URLs
+	avatar := user.Avatar
+	if user.AvatarKey != "" {
+		avatar = storage.URL(user.AvatarKey)
+	}
+
+	return models.PublicProfileDTO{
+		UserId:         user.UserId,
+		Username:       user.Username,
+		FirstName:      user.FirstName,
+		LastName:       user.LastName,
+		Avatar:         avatar,
+		PostKarma:      user.PostKarma,
+		CommentKarma:   user.CommentKarma,
+		TotalKarma:     user.PostKarma + user.CommentKarma,
+		FollowersCount: user.FollowersCount,
+		FollowingCount: user.FollowingCount,
+		CreatedAt:      user.CreatedAt,
+	}
+}
+
+// FindProfilesByIDs loads public profiles in the order of ids.
+func FindProfilesByIDs(ctx context.Context, ids []string) ([]models.PublicProfileDTO, error) {
+	profiles := []models.PublicProfileDTO{}
+	if len(ids) == 0 {
+		return profiles, nil
+	}
+
+	cursor, err := database.UserCollection.Find(ctx, bson.M{"user_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]models.User, len(users))
+	for _, user := range users {
+		byId[user.UserId] = user
+	}
+	for _, id := range ids {
+		if user, ok := byId[id]; ok {
+			profiles = append(profiles, PublicProfile(user))
+		}
+	}
+
+	return profiles, nil
+}
+
+// ProfileSort maps the new/top sort options of profile listings to a sort
+// order. Anything else sorts by new.
+func ProfileSort(sort string) bson.D {
+	if sort == "top" {
+		return bson.D{{Key: "score", Value: -1}, {Key: "created_at", Value: -1}}
+	}
+	return bson.D{{Key: "created_at", Value: -1}}
+}
+
+// UserOverview lists a user's posts and comments together in one sorted page.
+func UserOverview(ctx context.Context, userId string, sort bson.D, skip int64, limit int64) ([]models.OverviewItem, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"author_url": userId}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":           0,
+			"kind":          bson.M{"$literal": "post"},
+			"post_id":       1,
+			"title":         1,
+			"content":       1,
+			"subreddit_id":  1,
+			"score":         1,
+			"comment_count": 1,
+			"created_at":    1,
+		}}},
+		{{Key: "$unionWith", Value: bson.M{
+			"coll": database.CommentCollection.Name(),
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"author_url": userId}}},
+				{{Key: "$project", Value: bson.M{
+					"_id":           0,
+					"kind":          bson.M{"$literal": "comment"},
+					"post_id":       1,
+					"comment_id":    1,
+					"content":       1,
+					"score":         1,
+					"comment_count": 1,
+					"created_at":    1,
+				}}},
+			},
+		}}},
+		{{Key: "$sort", Value: sort}},
+		{{Key: "$skip", Value: skip}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := database.PostCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	items := []models.OverviewItem{}
+	err = cursor.All(ctx, &items)
+	return items, err
+}
+
+// FindPostsByIDs loads posts in the order of ids, skipping any that no
+// longer exist.
+func FindPostsByIDs(ctx context.Context, ids []string) ([]models.Post, error) {
+	posts := []models.Post{}
+	if len(ids) == 0 {
+		return posts, nil
+	}
+
+	cursor, err := database.PostCollection.Find(ctx, bson.M{"post_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var found []models.Post
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]models.Post, len(found))
+	for _, post := range found {
+		byId[post.PostID] = post
+	}
+	for _, id := range ids {
+		if post, ok := byId[id]; ok {
+			posts = append(posts, post)
+		}
+	}
+
+	return posts, nil
+}
+
+// FindCommentsByIDs loads comments keyed by comment id.
+func FindCommentsByIDs(ctx context.Context, ids []string) (map[string]models.Comment, error) {
+	comments := make(map[string]models.Comment)
+	if len(ids) == 0 {
+		return comments, nil
+	}
+
+	cursor, err := database.CommentCollection.Find(ctx, bson.M{"comment_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var found []models.Comment
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	for _, comment := range found {
+		comments[comment.CommentID] = comment
+	}
+
+	return comments, nil
+}
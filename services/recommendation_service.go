@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/config"
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/helpers"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	// interestSignals is how many recent upvotes, saves and comments each
+	// feed into the interest vector.
+	interestSignals = 50
+	// recommendationCandidates caps how many recent posts are compared.
+	recommendationCandidates = 1000
+	recommendationWindow     = 30 * 24 * time.Hour
+	// maxPerSubreddit keeps one subreddit from filling a page when others
+	// have candidates.
+	maxPerSubreddit = 3
+	seenTTL         = 7 * 24 * time.Hour
+	// maxSeen caps how many recently recommended posts are remembered and
+	// excluded, so the exclusion list stays small.
+	maxSeen = 500
+)
+
+// recommendationSeenKey is a sorted set of recommended post ids scored by
+// when they were recommended.
+func recommendationSeenKey(userId string) string {
+	return "feed:recommended:shown:" + userId
+}
+
+// recentPostIDs reads the post_id of the user's most recent documents in
+// collection.
+func recentPostIDs(ctx context.Context, collection *mongo.Collection, filter bson.M, sortField string) ([]string, error) {
+	findOptions := options.Find().
+		SetProjection(bson.M{"post_id": 1}).
+		SetSort(bson.D{{Key: sortField, Value: -1}}).
+		SetLimit(interestSignals)
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		PostID string `bson:"post_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	postIds := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		postIds = append(postIds, doc.PostID)
+	}
+	return postIds, nil
+}
+
+// InterestVector averages the embeddings of posts the user recently upvoted,
+// saved or commented on. It is nil for users with no such activity.
+func InterestVector(ctx context.Context, userId string) ([]float32, []string, error) {
+	upvoted, err := recentPostIDs(ctx, database.PostVoteCollection, bson.M{"user_id": userId, "value": models.UPVOTE}, "updated_at")
+	if err != nil {
+		return nil, nil, err
+	}
+	saved, err := recentPostIDs(ctx, database.SavedCollection, bson.M{"user_id": userId}, "created_at")
+	if err != nil {
+		return nil, nil, err
+	}
+	commented, err := recentPostIDs(ctx, database.CommentCollection, bson.M{"author_url": userId}, "created_at")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	postIds := append(append(upvoted, saved...), commented...)
+	if len(postIds) == 0 {
+		return nil, postIds, nil
+	}
+
+	findOptions := options.Find().SetProjection(bson.M{"post_id": 1, "embeddings": 1})
+	cursor, err := database.PostCollection.Find(ctx, bson.M{"post_id": bson.M{"$in": postIds}, "embeddings.0": bson.M{"$exists": true}}, findOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var posts []models.Post
+	if err := cursor.All(ctx, &posts); err != nil {
+		return nil, nil, err
+	}
+
+	// A post the user both upvoted and commented on counts twice
+	embeddings := make(map[string][]float32, len(posts))
+	for _, post := range posts {
+		embeddings[post.PostID] = post.Embeddings
+	}
+
+	var vectors [][]float32
+	for _, postId := range postIds {
+		if embedding, ok := embeddings[postId]; ok {
+			vectors = append(vectors, embedding)
+		}
+	}
+
+	return helpers.Centroid(vectors), postIds, nil
+}
+
+type scoredPost struct {
+	post  models.Post
+	score float64
+}
+
+// RecommendedPosts returns up to limit posts close to the user's interests
+// that they have not interacted with or been recommended recently.
+func RecommendedPosts(ctx context.Context, userId string, limit int) ([]models.Post, error) {
+	var user models.User
+	if err := database.UserCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user); err != nil {
+		return nil, err
+	}
+
+	interest, interacted, err := InterestVector(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden, err := HiddenPostIDs(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	seenKey := recommendationSeenKey(userId)
+	seenSince := strconv.FormatInt(time.Now().Add(-seenTTL).Unix(), 10)
+	seen, err := config.Redis.ZRevRangeByScore(ctx, seenKey, &redis.ZRangeBy{Min: seenSince, Max: "+inf", Count: maxSeen}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	blocked, err := BlockedUserIDs(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := append(append(interacted, hidden...), seen...)
+
+	filter := bson.M{
+		"post_id":      bson.M{"$nin": excluded},
+		"author_url":   bson.M{"$nin": append(blocked, userId)},
+		"embeddings.0": bson.M{"$exists": true},
+		"created_at":   bson.M{"$gte": time.Now().Add(-recommendationWindow)},
+	}
+	if !user.ShowNSFW {
+		filter["nsfw"] = bson.M{"$ne": true}
+	}
+
+	// Candidates only carry what ranking needs; full posts are loaded for
+	// the returned page alone
+	projection := bson.M{"post_id": 1, "subreddit_id": 1, "score": 1, "created_at": 1}
+	if interest != nil {
+		projection["embeddings"] = 1
+	}
+
+	findOptions := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(recommendationCandidates)
+
+	cursor, err := database.PostCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var candidates []models.Post
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	// Without any activity to learn from, fall back to what is hot
+	scored := make([]scoredPost, 0, len(candidates))
+	for _, post := range candidates {
+		score := helpers.HotScore(post.Score, post.CreatedAt)
+		if interest != nil {
+			score = float64(helpers.CosineSimilarity(interest, post.Embeddings))
+		}
+		scored = append(scored, scoredPost{post: post, score: score})
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+
+	posts, err := loadPosts(ctx, diversify(scored, limit))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(posts) > 0 {
+		now := float64(time.Now().Unix())
+		members := make([]redis.Z, 0, len(posts))
+		for _, post := range posts {
+			members = append(members, redis.Z{Score: now, Member: post.PostID})
+		}
+
+		pipe := config.Redis.TxPipeline()
+		pipe.ZAdd(ctx, seenKey, members...)
+		pipe.ZRemRangeByScore(ctx, seenKey, "-inf", "("+seenSince)
+		pipe.ZRemRangeByRank(ctx, seenKey, 0, -maxSeen-1)
+		pipe.Expire(ctx, seenKey, seenTTL)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return posts, nil
+}
+
+// loadPosts replaces ranked candidates with their full posts, keeping the
+// order and leaving out posts deleted in the meantime.
+func loadPosts(ctx context.Context, picked []models.Post) ([]models.Post, error) {
+	posts := make([]models.Post, 0, len(picked))
+	if len(picked) == 0 {
+		return posts, nil
+	}
+
+	postIds := make([]string, 0, len(picked))
+	for _, post := range picked {
+		postIds = append(postIds, post.PostID)
+	}
+
+	findOptions := options.Find().SetProjection(bson.M{"embeddings": 0})
+	cursor, err := database.PostCollection.Find(ctx, bson.M{"post_id": bson.M{"$in": postIds}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var found []models.Post
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]models.Post, len(found))
+	for _, post := range found {
+		byId[post.PostID] = post
+	}
+	for _, postId := range postIds {
+		if post, ok := byId[postId]; ok {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// diversify picks the best posts while allowing at most maxPerSubreddit from
+// any one subreddit, topping up from the rest if that leaves the page short.
+func diversify(scored []scoredPost, limit int) []models.Post {
+	posts := make([]models.Post, 0, limit)
+	picked := make(map[string]bool)
+	perSubreddit := make(map[string]int)
+
+	for _, candidate := range scored {
+		if len(posts) == limit {
+			break
+		}
+		subredditId := candidate.post.SubredditID
+		if perSubreddit[subredditId] >= maxPerSubreddit {
+			continue
+		}
+		perSubreddit[subredditId]++
+		picked[candidate.post.PostID] = true
+		posts = append(posts, candidate.post)
+	}
+
+	for _, candidate := range scored {
+		if len(posts) == limit {
+			break
+		}
+		if !picked[candidate.post.PostID] {
+			posts = append(posts, candidate.post)
+		}
+	}
+
+	return posts
+}
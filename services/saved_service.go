@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const DefaultSavedCollection = "default"
+
+// HiddenPostIDs lists the posts a user has hidden from their feeds.
+func HiddenPostIDs(ctx context.Context, userId string) ([]string, error) {
+	var postIds []string
+	if userId == "" {
+		return postIds, nil
+	}
+	err := database.HiddenPostCollection.Distinct(ctx, "post_id", bson.M{"user_id": userId}).Decode(&postIds)
+	return postIds, err
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrInsufficientKarma  = errors.New("not enough karma in this subreddit to post")
+	ErrBanned             = errors.New("you are banned from this subreddit")
+	ErrCrosspostsDisabled = errors.New("this subreddit does not accept crossposts")
+	ErrInvalidBan         = errors.New("ban length must be 0 (permanent) or more days")
+	ErrBanModerator       = errors.New("moderators cannot be banned")
+)
+
+// CheckPostingRules returns an error when a ban or the subreddit's rules stop
+// the user from posting in it. Posts without a subreddit have no rules to
+// check.
+func CheckPostingRules(ctx context.Context, userId string, subredditId string) error {
+	_, err := checkPostingRules(ctx, userId, subredditId)
+	return err
+}
+
+// CheckCrosspostRules is CheckPostingRules for sharing a post into the
+// subreddit, which can also turn crossposts off.
+func CheckCrosspostRules(ctx context.Context, userId string, subredditId string) error {
+	subreddit, err := checkPostingRules(ctx, userId, subredditId)
+	if err != nil {
+		return err
+	}
+	if subreddit.Rules.CrosspostsDisabled {
+		return ErrCrosspostsDisabled
+	}
+	return nil
+}
+
+func checkPostingRules(ctx context.Context, userId string, subredditId string) (models.SubReddit, error) {
+	var subreddit models.SubReddit
+	if subredditId == "" {
+		return subreddit, nil
+	}
+
+	if err := database.SubredditCollection.FindOne(ctx, bson.M{"subreddit_id": subredditId}).Decode(&subreddit); err != nil {
+		return subreddit, err
+	}
+
+	banned, err := IsBanned(ctx, userId, subredditId)
+	if err != nil {
+		return subreddit, err
+	}
+	if banned {
+		return subreddit, ErrBanned
+	}
+
+	if subreddit.Rules.MinKarmaToPost > 0 {
+		// Moderators are exempt so a new subreddit's own team can post
+		moderator, err := IsModerator(ctx, userId, subredditId)
+		if err != nil {
+			return subreddit, err
+		}
+		if moderator {
+			return subreddit, nil
+		}
+
+		karma, err := SubredditKarma(ctx, userId, subredditId)
+		if err != nil {
+			return subreddit, err
+		}
+		if karma < subreddit.Rules.MinKarmaToPost {
+			return subreddit, ErrInsufficientKarma
+		}
+	}
+
+	return subreddit, nil
+}
+
+// IsBanned reports whether the user has an unexpired ban in the subreddit.
+func IsBanned(ctx context.Context, userId string, subredditId string) (bool, error) {
+	count, err := database.SubredditBanCollection.CountDocuments(ctx, bson.M{
+		"user_id":      userId,
+		"subreddit_id": subredditId,
+		"$or": []bson.M{
+			{"expires_at": nil},
+			{"expires_at": bson.M{"$gt": time.Now()}},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// BanUser bans a user from posting in a subreddit, replacing any earlier
+// ban. Moderators cannot be banned.
+func BanUser(ctx context.Context, subredditId string, userId string, bannedBy string, dto models.BanDTO) (models.SubredditBan, error) {
+	ban := models.SubredditBan{
+		SubredditID: subredditId,
+		UserID:      userId,
+		BannedBy:    bannedBy,
+		Reason:      dto.Reason,
+		CreatedAt:   time.Now(),
+	}
+	if dto.Days < 0 {
+		return ban, ErrInvalidBan
+	}
+	if dto.Days > 0 {
+		expiresAt := time.Now().AddDate(0, 0, dto.Days)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	moderator, err := IsModerator(ctx, userId, subredditId)
+	if err != nil {
+		return ban, err
+	}
+	if moderator {
+		return ban, ErrBanModerator
+	}
+
+	_, err = database.SubredditBanCollection.ReplaceOne(
+		ctx,
+		bson.M{"subreddit_id": subredditId, "user_id": userId},
+		ban,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return ban, err
+	}
+
+	return ban, LogModAction(ctx, subredditId, bannedBy, models.MOD_BAN, "user", userId, dto.Reason)
+}
+
+func UnbanUser(ctx context.Context, subredditId string, userId string, moderatorId string) (bool, error) {
+	result, err := database.SubredditBanCollection.DeleteOne(ctx, bson.M{"subreddit_id": subredditId, "user_id": userId})
+	if err != nil || result.DeletedCount == 0 {
+		return false, err
+	}
+
+	return true, LogModAction(ctx, subredditId, moderatorId, models.MOD_UNBAN, "user", userId, "")
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/config"
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	centroidCacheKey = "ai:subreddit_centroids"
+	// centroidCacheTTL is how stale subreddit centroids can be. A new post
+	// moves its subreddit's centroid very little, so a few minutes is fine.
+	centroidCacheTTL = 15 * time.Minute
+)
+
+// SubredditCentroids returns the mean post embedding of every subreddit with
+// embedded posts, keyed by subreddit_id. The means are averaged by the
+// database over all of a subreddit's posts, so quiet subreddits are included,
+// and cached for centroidCacheTTL.
+func SubredditCentroids(ctx context.Context) (map[string][]float32, error) {
+	var centroids map[string][]float32
+
+	data, err := config.Redis.Get(ctx, centroidCacheKey).Bytes()
+	if err == nil {
+		if err = json.Unmarshal(data, &centroids); err == nil {
+			return centroids, nil
+		}
+	}
+	if !errors.Is(err, redis.Nil) {
+		fmt.Println("Error reading cached subreddit centroids:", err.Error())
+	}
+
+	centroids, err = subredditCentroids(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(centroids); err == nil {
+		if err := config.Redis.Set(ctx, centroidCacheKey, data, centroidCacheTTL).Err(); err != nil {
+			fmt.Println("Error caching subreddit centroids:", err.Error())
+		}
+	}
+
+	return centroids, nil
+}
+
+// subredditCentroids averages each embedding dimension per subreddit and
+// reassembles the dimensions in order.
+func subredditCentroids(ctx context.Context) (map[string][]float32, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"subreddit_id": bson.M{"$ne": ""}, "embeddings.0": bson.M{"$exists": true}}}},
+		{{Key: "$project", Value: bson.M{"subreddit_id": 1, "embeddings": 1}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$embeddings", "includeArrayIndex": "dimension"}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"subreddit_id": "$subreddit_id", "dimension": "$dimension"},
+			"value": bson.M{"$avg": "$embeddings"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.subreddit_id", Value: 1}, {Key: "_id.dimension", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$_id.subreddit_id", "centroid": bson.M{"$push": "$value"}}}},
+	}
+
+	cursor, err := database.PostCollection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		SubredditID string    `bson:"_id"`
+		Centroid    []float64 `bson:"centroid"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	centroids := make(map[string][]float32, len(results))
+	for _, result := range results {
+		centroid := make([]float32, len(result.Centroid))
+		for i, value := range result.Centroid {
+			centroid[i] = float32(value)
+		}
+		centroids[result.SubredditID] = centroid
+	}
+
+	return centroids, nil
+}
+
+// EachSubredditEmbedding calls visit with every embedded post in the
+// subreddit. Posts are streamed rather than loaded at once so a large
+// subreddit is still searched in full.
+func EachSubredditEmbedding(ctx context.Context, subredditId string, visit func(post models.Post)) error {
+	findOptions := options.Find().
+		SetProjection(bson.M{"post_id": 1, "title": 1, "subreddit_id": 1, "tags": 1, "embeddings": 1})
+
+	cursor, err := database.PostCollection.Find(ctx, bson.M{
+		"subreddit_id": subredditId,
+		"embeddings.0": bson.M{"$exists": true},
+	}, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var post models.Post
+		if err := cursor.Decode(&post); err != nil {
+			return err
+		}
+		visit(post)
+	}
+
+	return cursor.Err()
+}
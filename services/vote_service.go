@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EsanSamuel/Reddit_Clone/database"
+	"github.com/EsanSamuel/Reddit_Clone/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrInvalidVote = errors.New("vote value must be 1, 0 or -1")
+
+// voteCounts returns how the up_vote and down_vote counters move when a vote
+// changes from previous to value. down_vote is kept as a negative count.
+func voteCounts(previous int, value int) (int, int) {
+	up, down := 0, 0
+	if previous == models.UPVOTE {
+		up--
+	}
+	if previous == models.DOWNVOTE {
+		down++
+	}
+	if value == models.UPVOTE {
+		up++
+	}
+	if value == models.DOWNVOTE {
+		down--
+	}
+	return up, down
+}
+
+// VotePost casts, switches or retracts (value 0) a user's vote on a post and
+// moves the post score and the author's karma by the difference, all in one
+// transaction.
+func VotePost(ctx context.Context, userId string, postId string, value int) (models.Post, models.VoteResult, error) {
+	var post models.Post
+	var result models.VoteResult
+
+	if value != models.UPVOTE && value != models.DOWNVOTE && value != models.NO_VOTE {
+		return post, result, ErrInvalidVote
+	}
+
+	err := database.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": postId}).Decode(&post); err != nil {
+			return err
+		}
+		if post.Archived {
+			return ErrPostArchived
+		}
+
+		filter := bson.M{"user_id": userId, "post_id": postId}
+
+		var existing models.PostVote
+		err := database.PostVoteCollection.FindOne(ctx, filter).Decode(&existing)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		result = models.VoteResult{Previous: existing.Value, Value: value, Score: post.Score}
+		if existing.Value == value {
+			return nil
+		}
+
+		if value == models.NO_VOTE {
+			_, err = database.PostVoteCollection.DeleteOne(ctx, filter)
+		} else {
+			now := time.Now()
+			_, err = database.PostVoteCollection.UpdateOne(ctx, filter, bson.M{
+				"$set": bson.M{"value": value, "updated_at": now},
+				"$setOnInsert": bson.M{
+					"author_id":    post.AuthorID,
+					"subreddit_id": post.SubredditID,
+					"created_at":   now,
+				},
+			}, options.UpdateOne().SetUpsert(true))
+		}
+		if err != nil {
+			return err
+		}
+
+		delta := value - existing.Value
+		up, down := voteCounts(existing.Value, value)
+
+		err = database.PostCollection.FindOneAndUpdate(
+			ctx,
+			bson.M{"post_id": postId},
+			bson.M{"$inc": bson.M{"up_vote": up, "down_vote": down, "score": delta}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&post)
+		if err != nil {
+			return err
+		}
+
+		result.Score = post.Score
+		result.Changed = true
+
+		return addKarma(ctx, post.AuthorID, post.SubredditID, "post_karma", delta)
+	})
+	if err != nil {
+		return post, result, fmt.Errorf("voting on post %s: %w", postId, err)
+	}
+
+	return post, result, nil
+}
+
+// VoteComment is VotePost for comments; it moves comment karma instead.
+func VoteComment(ctx context.Context, userId string, commentId string, value int) (models.Comment, models.VoteResult, error) {
+	var comment models.Comment
+	var result models.VoteResult
+
+	if value != models.UPVOTE && value != models.DOWNVOTE && value != models.NO_VOTE {
+		return comment, result, ErrInvalidVote
+	}
+
+	err := database.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := database.CommentCollection.FindOne(ctx, bson.M{"comment_id": commentId}).Decode(&comment); err != nil {
+			return err
+		}
+
+		var post models.Post
+		findOptions := options.FindOne().SetProjection(bson.M{"subreddit_id": 1, "archived": 1})
+		if err := database.PostCollection.FindOne(ctx, bson.M{"post_id": comment.PostID}, findOptions).Decode(&post); err != nil {
+			return err
+		}
+		if post.Archived {
+			return ErrPostArchived
+		}
+
+		filter := bson.M{"user_id": userId, "comment_id": commentId}
+
+		var existing models.CommentVote
+		err := database.CommentVoteCollection.FindOne(ctx, filter).Decode(&existing)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		result = models.VoteResult{Previous: existing.Value, Value: value, Score: comment.Score}
+		if existing.Value == value {
+			return nil
+		}
+
+		if value == models.NO_VOTE {
+			_, err = database.CommentVoteCollection.DeleteOne(ctx, filter)
+		} else {
+			now := time.Now()
+			_, err = database.CommentVoteCollection.UpdateOne(ctx, filter, bson.M{
+				"$set": bson.M{"value": value, "updated_at": now},
+				"$setOnInsert": bson.M{
+					"post_id":      comment.PostID,
+					"author_id":    comment.AuthorID,
+					"subreddit_id": post.SubredditID,
+					"created_at":   now,
+				},
+			}, options.UpdateOne().SetUpsert(true))
+		}
+		if err != nil {
+			return err
+		}
+
+		delta := value - existing.Value
+
+		err = database.CommentCollection.FindOneAndUpdate(
+			ctx,
+			bson.M{"comment_id": commentId},
+			bson.M{"$inc": bson.M{"score": delta}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&comment)
+		if err != nil {
+			return err
+		}
+
+		result.Score = comment.Score
+		result.Changed = true
+
+		return addKarma(ctx, comment.AuthorID, post.SubredditID, "comment_karma", delta)
+	})
+	if err != nil {
+		return comment, result, fmt.Errorf("voting on comment %s: %w", commentId, err)
+	}
+
+	return comment, result, nil
+}
+
+// addKarma moves a user's total and per-subreddit karma. field is either
+// post_karma or comment_karma.
+func addKarma(ctx context.Context, userId string, subredditId string, field string, delta int) error {
+	if userId == "" || delta == 0 {
+		return nil
+	}
+
+	_, err := database.UserCollection.UpdateOne(ctx, bson.M{"user_id": userId}, bson.M{"$inc": bson.M{field: delta}})
+	if err != nil {
+		return err
+	}
+
+	if subredditId == "" {
+		return nil
+	}
+
+	_, err = database.SubredditKarmaCollection.UpdateOne(
+		ctx,
+		bson.M{"user_id": userId, "subreddit_id": subredditId},
+		bson.M{
+			"$inc": bson.M{field: delta},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+		options.UpdateOne().SetUpsert(true),
+	)
+	return err
+}
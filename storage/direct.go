@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MinPartSize is the smallest part S3 accepts in a multipart upload, except
+// for the last one.
+const MinPartSize = 5 << 20
+
+var ErrDirectUploadUnsupported = errors.New("storage driver does not support direct uploads")
+
+// Part is an uploaded part of a multipart upload, identified by the ETag
+// the store returned for it.
+type Part struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+// DirectUploader is implemented by stores that clients can upload to
+// without streaming through the API, either with one presigned PUT or in
+// parts that can be retried on their own. maxSize is the most a signed URL
+// accepts.
+type DirectUploader interface {
+	SignedPutURL(key string, contentType string, maxSize int64, expires time.Duration) (string, error)
+	CreateMultipart(ctx context.Context, key string, contentType string) (string, error)
+	SignedPartURL(key string, uploadId string, partNumber int, maxSize int64, expires time.Duration) (string, error)
+	CompleteMultipart(ctx context.Context, key string, uploadId string, parts []Part) error
+	AbortMultipart(ctx context.Context, key string, uploadId string) error
+}
+
+// Direct returns the default store as a DirectUploader.
+func Direct() (DirectUploader, error) {
+	direct, ok := Default().(DirectUploader)
+	if !ok {
+		return nil, ErrDirectUploadUnsupported
+	}
+	return direct, nil
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// LocalStore keeps objects on disk under dir. They are served by the
+// /files route, which only answers requests carrying a valid signature.
+type LocalStore struct {
+	dir        string
+	baseURL    string
+	signingKey []byte
+}
+
+func NewLocalStore(dir string, baseURL string, signingKey string) (*LocalStore, error) {
+	if signingKey == "" {
+		return nil, errors.New("STORAGE_SIGNING_KEY or JWT_SECRET_KEY must be set to sign local file urls")
+	}
+
+	return &LocalStore{
+		dir:        dir,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		signingKey: []byte(signingKey),
+	}, nil
+}
+
+func (s *LocalStore) Dir() string {
+	return s.dir
+}
+
+// path maps a key to a file inside dir, rejecting keys that would escape it.
+func (s *LocalStore) path(key string) (string, error) {
+	if !filepath.IsLocal(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first so readers never see a partial
+// object.
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// signature covers the method, so a download link cannot be used to
+// overwrite the object, and for parts the upload and part number.
+func (s *LocalStore) signature(method string, key string, expires int64, uploadId string, partNumber int, maxSize int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	fmt.Fprintf(mac, "%s:%s:%d:%s:%d:%d", method, key, expires, uploadId, partNumber, maxSize)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *LocalStore) signedURL(method string, key string, expires time.Duration, uploadId string, partNumber int, maxSize int64) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(expires).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt, 10))
+	if uploadId != "" {
+		query.Set("upload_id", uploadId)
+		query.Set("part_number", strconv.Itoa(partNumber))
+	}
+	if maxSize > 0 {
+		query.Set("max_size", strconv.FormatInt(maxSize, 10))
+	}
+	query.Set("signature", s.signature(method, key, expiresAt, uploadId, partNumber, maxSize))
+
+	return s.baseURL + (&url.URL{Path: "/" + key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+func (s *LocalStore) SignedURL(key string, expires time.Duration) (string, error) {
+	return s.signedURL(http.MethodGet, key, expires, "", 0, 0)
+}
+
+// Verify reports whether a signature from one of the signed URLs is valid
+// and unexpired for the request method.
+func (s *LocalStore) Verify(method string, key string, query url.Values) bool {
+	expiresAt, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+
+	partNumber := 0
+	if query.Has("part_number") {
+		if partNumber, err = strconv.Atoi(query.Get("part_number")); err != nil {
+			return false
+		}
+	}
+
+	maxSize, err := maxSize(query)
+	if err != nil {
+		return false
+	}
+
+	expected := s.signature(method, key, expiresAt, query.Get("upload_id"), partNumber, maxSize)
+	return hmac.Equal([]byte(query.Get("signature")), []byte(expected))
+}
+
+// maxSize reads the size limit signed into an upload URL, 0 when it has none.
+func maxSize(query url.Values) (int64, error) {
+	if !query.Has("max_size") {
+		return 0, nil
+	}
+	return strconv.ParseInt(query.Get("max_size"), 10, 64)
+}
+
+// VerifySignature guards the routes serving and receiving the store's files.
+func (s *LocalStore) VerifySignature() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("filepath"), "/")
+
+		method := c.Request.Method
+		if method == http.MethodHead {
+			method = http.MethodGet
+		}
+
+		if !s.Verify(method, key, c.Request.URL.Query()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid or expired link"})
+			return
+		}
+
+		c.Next()
+	}
+}
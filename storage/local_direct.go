@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrMissingPart = errors.New("multipart upload is missing a part")
+
+// multipartDir holds the parts of an unfinished upload.
+func (s *LocalStore) multipartDir(uploadId string) (string, error) {
+	if uploadId == "" || !filepath.IsLocal(uploadId) || strings.ContainsAny(uploadId, `/\`) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, ".multipart", uploadId), nil
+}
+
+func (s *LocalStore) SignedPutURL(key string, contentType string, maxSize int64, expires time.Duration) (string, error) {
+	return s.signedURL(http.MethodPut, key, expires, "", 0, maxSize)
+}
+
+func (s *LocalStore) CreateMultipart(ctx context.Context, key string, contentType string) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	uploadId := hex.EncodeToString(id)
+
+	dir, err := s.multipartDir(uploadId)
+	if err != nil {
+		return "", err
+	}
+	return uploadId, os.MkdirAll(dir, 0o755)
+}
+
+func (s *LocalStore) SignedPartURL(key string, uploadId string, partNumber int, maxSize int64, expires time.Duration) (string, error) {
+	return s.signedURL(http.MethodPut, key, expires, uploadId, partNumber, maxSize)
+}
+
+// CompleteMultipart joins the parts in part number order into the object.
+func (s *LocalStore) CompleteMultipart(ctx context.Context, key string, uploadId string, parts []Part) error {
+	dir, err := s.multipartDir(uploadId)
+	if err != nil {
+		return err
+	}
+
+	parts = slices.Clone(parts)
+	slices.SortFunc(parts, func(a, b Part) int { return a.PartNumber - b.PartNumber })
+
+	readers := make([]io.Reader, 0, len(parts))
+	for _, part := range parts {
+		file, err := os.Open(filepath.Join(dir, strconv.Itoa(part.PartNumber)))
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %d", ErrMissingPart, part.PartNumber)
+		}
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+
+	if err := s.Put(ctx, key, io.MultiReader(readers...), ""); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
+
+func (s *LocalStore) AbortMultipart(ctx context.Context, key string, uploadId string) error {
+	dir, err := s.multipartDir(uploadId)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// putPart stores one part and returns its ETag, an MD5 like S3's.
+func (s *LocalStore) putPart(uploadId string, partNumber int, body io.Reader) (string, error) {
+	dir, err := s.multipartDir(uploadId)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp(dir, ".part-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	hash := md5.New()
+	if _, err := io.Copy(io.MultiWriter(file, hash), body); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(file.Name(), filepath.Join(dir, strconv.Itoa(partNumber))); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)) + `"`, nil
+}
+
+// ReceiveUpload answers the presigned PUT and part URLs. It must run behind
+// VerifySignature, which checks the size limit signed into the URL.
+func (s *LocalStore) ReceiveUpload() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("filepath"), "/")
+
+		limit, err := maxSize(c.Request.URL.Query())
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Upload link has no size limit"})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		if uploadId := c.Query("upload_id"); uploadId != "" {
+			partNumber, err := strconv.Atoi(c.Query("part_number"))
+			if err != nil || partNumber < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid part number"})
+				return
+			}
+
+			etag, err := s.putPart(uploadId, partNumber, c.Request.Body)
+			if tooLarge(err) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Part is larger than the upload allows"})
+				return
+			}
+			if errors.Is(err, fs.ErrNotExist) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error storing part", "details": err.Error()})
+				return
+			}
+
+			c.Header("ETag", etag)
+			c.Status(http.StatusOK)
+			return
+		}
+
+		err = s.Put(c.Request.Context(), key, c.Request.Body, c.GetHeader("Content-Type"))
+		if tooLarge(err) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is larger than the upload allows"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error storing file", "details": err.Error()})
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
+
+func tooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
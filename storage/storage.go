@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// BlobStore keeps uploaded files. Drivers are selected with STORAGE_DRIVER.
+// Objects are private; clients read them through a signed URL.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	SignedURL(key string, expires time.Duration) (string, error)
+}
+
+var (
+	defaultStore BlobStore
+	defaultErr   error
+	defaultOnce  sync.Once
+)
+
+// Init configures the default store from the environment. It runs at
+// startup so a misconfigured store stops the server instead of uploads
+// quietly going somewhere they are lost.
+func Init() error {
+	defaultOnce.Do(func() {
+		defaultStore, defaultErr = FromEnv()
+	})
+	return defaultErr
+}
+
+// Default returns the store configured by the environment. It panics when
+// the configuration is invalid, which Init reports at startup.
+func Default() BlobStore {
+	if err := Init(); err != nil {
+		panic(fmt.Sprintf("storage is not configured: %v", err))
+	}
+	return defaultStore
+}
+
+// FromEnv builds a store from STORAGE_DRIVER: s3, local or memory. Without
+// a driver it uses s3 when AWS_BUCKET_NAME is set and the local disk
+// otherwise, so local runs never need an S3 endpoint. An unknown driver, a
+// missing bucket or a local store without a signing key is an error.
+func FromEnv() (BlobStore, error) {
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+	if driver == "" {
+		driver = "local"
+		if os.Getenv("AWS_BUCKET_NAME") != "" {
+			driver = "s3"
+		}
+	}
+
+	switch driver {
+	case "s3":
+		return NewS3Store(
+			os.Getenv("AWS_BUCKET_NAME"),
+			os.Getenv("AWS_REGION"),
+			os.Getenv("AWS_ENDPOINT"),
+			os.Getenv("AWS_ACCESS_KEY_ID"),
+			os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		)
+	case "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "tmp/storage"
+		}
+		baseURL := os.Getenv("STORAGE_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:8080/files"
+		}
+		signingKey := os.Getenv("STORAGE_SIGNING_KEY")
+		if signingKey == "" {
+			signingKey = os.Getenv("JWT_SECRET_KEY")
+		}
+		return NewLocalStore(dir, baseURL, signingKey)
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+// URLTTL is how long a signed URL handed to a client stays valid.
+func URLTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("STORAGE_URL_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return time.Hour
+}
+
+// URL signs key with the default store. It returns an empty string when
+// there is no key or it cannot be signed.
+func URL(key string) string {
+	if key == "" {
+		return ""
+	}
+
+	url, err := Default().SignedURL(key, URLTTL())
+	if err != nil {
+		fmt.Println("Error signing storage url:", err.Error())
+		return ""
+	}
+	return url
+}
+
+// ReadAll fetches an object into memory.
+func ReadAll(ctx context.Context, store BlobStore, key string) ([]byte, error) {
+	body, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
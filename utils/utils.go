@@ -6,20 +6,14 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"mime/multipart"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/EsanSamuel/Reddit_Clone/database"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/resend/resend-go/v3"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -128,75 +122,20 @@ func GenerateVerificationOrResetToken() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
-func SendVerificationEmail(email string, verificationToken string) (string, error) {
-	url := "http://localhost:3000/verify-email?token=" + verificationToken
-	RESEND_API_KEY := os.Getenv("RESEND_API_KEY")
-
-	client := resend.NewClient(RESEND_API_KEY)
-
-	params := &resend.SendEmailRequest{
-		From: "Acme <noreply@mikaelsoninitiative.org>",
-		To:   []string{email},
-		Html: `<div style="max-width: 500px; margin: 0 auto; font-family: Arial, sans-serif; background-color: #ffffff; padding: 30px; border-radius: 8px; border: 1px solid #e5e7eb;">
-  
-  <h2 style="color: #111827; text-align: center; margin-bottom: 10px;">
-    Confirm Your Signup
-  </h2>
-
-  <p style="color: #374151; font-size: 15px; text-align: center;">
-    Hey there 👋
-  </p>
-
-  <p style="color: #374151; font-size: 15px; text-align: center; line-height: 1.5;">
-    Thanks for joining <b>Reddit</b>! Please confirm your email address to activate your account.
-  </p>
-
-  <div style="text-align: center; margin: 30px 0;">
-    <a href="` + url + `"
-       style="
-         background-color: #2563eb;
-         color: #ffffff;
-         padding: 14px 30px;
-         text-decoration: none;
-         border-radius: 6px;
-         font-weight: bold;
-         display: inline-block;
-         font-size: 16px;
-       ">
-      Confirm Email
-    </a>
-  </div>
-
-  <p style="color: #6b7280; font-size: 14px; text-align: center; line-height: 1.4;">
-    If you didn’t sign up, you can safely ignore this email.
-  </p>
-
-</div>`,
-		Subject: "Hello from Golang",
-		Cc:      []string{"cc@example.com"},
-		Bcc:     []string{"bcc@example.com"},
-		ReplyTo: "replyto@example.com",
-	}
-
-	sent, err := client.Emails.Send(params)
-	if err != nil {
-		fmt.Println(err.Error())
-		return "", err
-	}
-	fmt.Println(sent.Id)
-	return sent.Id, nil
-}
-
+// GetAuthToken returns the token from an "Authorization: Bearer <token>"
+// header.
 func GetAuthToken(c *gin.Context) (string, error) {
 	authHeader := c.Request.Header.Get("Authorization")
 
 	if authHeader == "" {
-		fmt.Println("There is no authorization token")
 		return "", fmt.Errorf("Authorization token not found")
 	}
 
-	authToken := strings.Split(authHeader, " ")[1]
-	fmt.Println("TokenString:", authToken)
+	scheme, authToken, ok := strings.Cut(strings.TrimSpace(authHeader), " ")
+	authToken = strings.TrimSpace(authToken)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || authToken == "" {
+		return "", fmt.Errorf("Authorization header must be Bearer <token>")
+	}
 
 	return authToken, nil
 }
@@ -222,107 +161,3 @@ func VerifyAuthToken(tokenString string) (*SignedDetails, error) {
 
 	return claims, nil
 }
-
-type UploadResult struct {
-	Index int
-	URL   string
-}
-
-func uploadToS3(file multipart.File, filename string, index int, resultChan chan<- UploadResult) (string, error) {
-	defer file.Close()
-
-	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
-	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
-	bucketName := os.Getenv("AWS_BUCKET_NAME")
-	UPLOAD_URL := os.Getenv("UPLOAD_URL")
-	AWS_ENDPOINT := os.Getenv("AWS_ENDPOINT")
-	AWS_REGION := os.Getenv("AWS_REGION")
-
-	sess, err := session.NewSession(&aws.Config{
-		Region:   aws.String(AWS_REGION),
-		Endpoint: aws.String(AWS_ENDPOINT),
-		Credentials: credentials.NewStaticCredentials(
-			accessKey, secretKey, "",
-		),
-		S3ForcePathStyle: aws.Bool(true),
-	})
-	if err != nil {
-		return "", err
-	}
-
-	s3Client := s3.New(sess)
-	_, err = s3Client.PutObject(&s3.PutObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(filename),
-		Body:   file,
-		ACL:    aws.String("public-read"),
-	})
-
-	url := UPLOAD_URL + filename
-
-	resultChan <- UploadResult{Index: index, URL: url}
-
-	return url, err
-}
-
-func UploadFiles(c *gin.Context, files []*multipart.FileHeader) []string {
-	result := make(chan UploadResult, len(files))
-	urls := make([]string, len(files))
-
-	for index, file := range files {
-		src, err := file.Open()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error opening file", "details": err.Error()})
-			continue
-		}
-		go uploadToS3(src, file.Filename, index, result)
-	}
-
-	for i := 0; i < len(files); i++ {
-		res := <-result
-		urls[res.Index] = res.URL
-	}
-
-	fmt.Println("All uploaded urls:", urls)
-	return urls
-}
-
-func UploadSingleFileToS3(file multipart.File, filename string) (string, error) {
-	defer file.Close()
-
-	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
-	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
-	bucketName := os.Getenv("AWS_BUCKET_NAME")
-	UPLOAD_URL := os.Getenv("UPLOAD_URL")
-	AWS_ENDPOINT := os.Getenv("AWS_ENDPOINT")
-	AWS_REGION := os.Getenv("AWS_REGION")
-
-	sess, err := session.NewSession(&aws.Config{
-		Region:   aws.String(AWS_REGION),
-		Endpoint: aws.String(AWS_ENDPOINT),
-		Credentials: credentials.NewStaticCredentials(
-			accessKey, secretKey, "",
-		),
-		S3ForcePathStyle: aws.Bool(true),
-	})
-	if err != nil {
-		return "", err
-	}
-
-	s3Client := s3.New(sess)
-	_, err = s3Client.PutObject(&s3.PutObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(filename),
-		Body:   file,
-		ACL:    aws.String("public-read"),
-	})
-
-	url := UPLOAD_URL + filename
-
-	return url, err
-}
-
-func IsFileImage(file []byte) bool {
-	fileType := http.DetectContentType(file)
-	return strings.HasPrefix(fileType, "image/")
-}